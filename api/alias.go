@@ -0,0 +1,284 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// RenameImportAlias renames the alias of an app import and updates all the '#alias' refs that use it
+func RenameImportAlias(project common.AppProject, oldAlias, newAlias, contribType string) error {
+
+	oldAlias = strings.TrimPrefix(strings.TrimSpace(oldAlias), "#")
+	newAlias = strings.TrimPrefix(strings.TrimSpace(newAlias), "#")
+
+	if newAlias == "" || strings.ContainsAny(newAlias, " @:#/") {
+		return fmt.Errorf("invalid alias '%s'", newAlias)
+	}
+
+	if oldAlias == newAlias {
+		return fmt.Errorf("alias '%s' unchanged", oldAlias)
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return err
+	}
+
+	details, err := findAliasedImport(appImports, oldAlias, contribType)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range appImports.GetAllImportDetails() {
+		if other.TopLevel && other != details && other.Imp.CanonicalAlias() == newAlias {
+			return fmt.Errorf("alias '%s' is already used by import '%s'", newAlias, other.Imp.CanonicalImport())
+		}
+	}
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	imp := details.Imp
+	renamed := util.NewAIflowImport(imp.ModulePath(), imp.RelativeImportPath(), imp.Version(), newAlias)
+	if !replaceImportInMap(appObj, imp, renamed) {
+		return fmt.Errorf("unable to find import '%s' in %s", imp.CanonicalImport(), fileAIflowJson)
+	}
+
+	refType := importContribType(details)
+	count := util.RewriteAppRefs(appObj, func(ref string, ct string) (string, bool) {
+		if strings.TrimSpace(ref) == "#"+oldAlias && (refType == "" || ct == refType) {
+			return "#" + newAlias, true
+		}
+		return ref, false
+	})
+
+	err = writeAppDescriptorMap(project, appObj)
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Renamed alias '%s' to '%s' (%d refs updated)\n", oldAlias, newAlias, count)
+
+	return nil
+}
+
+// QualifyImportRefs replaces all the '#alias' refs with the fully qualified import path
+func QualifyImportRefs(project common.AppProject, alias, contribType string) error {
+
+	alias = strings.TrimPrefix(strings.TrimSpace(alias), "#")
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return err
+	}
+
+	details, err := findAliasedImport(appImports, alias, contribType)
+	if err != nil {
+		return err
+	}
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	refType := importContribType(details)
+	count := util.RewriteAppRefs(appObj, func(ref string, ct string) (string, bool) {
+		if strings.TrimSpace(ref) == "#"+alias && (refType == "" || ct == refType) {
+			return details.Imp.GoImportPath(), true
+		}
+		return ref, false
+	})
+
+	err = writeAppDescriptorMap(project, appObj)
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Qualified refs for alias '%s' (%d refs updated)\n", alias, count)
+
+	return nil
+}
+
+// UnqualifyImportRefs replaces all the fully qualified refs to an aliased import with '#alias'
+func UnqualifyImportRefs(project common.AppProject, alias, contribType string) error {
+
+	alias = strings.TrimPrefix(strings.TrimSpace(alias), "#")
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return err
+	}
+
+	details, err := findAliasedImport(appImports, alias, contribType)
+	if err != nil {
+		return err
+	}
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	refType := importContribType(details)
+	count := util.RewriteAppRefs(appObj, func(ref string, ct string) (string, bool) {
+		cleanedRef := strings.TrimSpace(ref)
+		if cleanedRef == "" || cleanedRef[0] == '#' || (refType != "" && ct != refType) {
+			return ref, false
+		}
+
+		refImport, err := util.ParseImport(cleanedRef)
+		if err != nil || refImport.GoImportPath() != details.Imp.GoImportPath() {
+			return ref, false
+		}
+
+		return "#" + alias, true
+	})
+
+	err = writeAppDescriptorMap(project, appObj)
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Replaced qualified refs with alias '%s' (%d refs updated)\n", alias, count)
+
+	return nil
+}
+
+// findAliasedImport finds the toplevel import for the alias, the contribType is only required
+// when the alias is used by imports of different contribution types
+func findAliasedImport(appImports *util.AppImports, alias, contribType string) (*util.AppImportDetails, error) {
+
+	var found []*util.AppImportDetails
+	for _, details := range appImports.GetAllImportDetails() {
+		if !details.TopLevel || details.Imp.CanonicalAlias() != alias {
+			continue
+		}
+		if contribType != "" && importContribType(details) != contribType {
+			continue
+		}
+		found = append(found, details)
+	}
+
+	switch len(found) {
+	case 0:
+		if contribType != "" {
+			return nil, fmt.Errorf("no %s import found with alias '%s'", contribType, alias)
+		}
+		return nil, fmt.Errorf("no import found with alias '%s'", alias)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("alias '%s' is used by %d imports, specify the contribution type", alias, len(found))
+	}
+}
+
+func importContribType(details *util.AppImportDetails) string {
+	if details.ContribDesc == nil {
+		return ""
+	}
+	return details.ContribDesc.GetContribType()
+}
+
+func replaceImportInMap(appObj map[string]interface{}, oldImport, newImport util.Import) bool {
+
+	imports, ok := appObj["imports"].([]interface{})
+	if !ok {
+		return false
+	}
+
+	for i, val := range imports {
+		strVal, ok := val.(string)
+		if !ok {
+			continue
+		}
+
+		imp, err := util.ParseImport(strVal)
+		if err != nil {
+			continue
+		}
+
+		if imp.GoImportPath() == oldImport.GoImportPath() && imp.CanonicalAlias() == oldImport.CanonicalAlias() {
+			imports[i] = newImport.CanonicalImport()
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeImportFromMap(appObj map[string]interface{}, imp util.Import) bool {
+
+	imports, ok := appObj["imports"].([]interface{})
+	if !ok {
+		return false
+	}
+
+	for i, val := range imports {
+		strVal, ok := val.(string)
+		if !ok {
+			continue
+		}
+
+		existing, err := util.ParseImport(strVal)
+		if err != nil || existing.GoImportPath() != imp.GoImportPath() {
+			continue
+		}
+
+		appObj["imports"] = append(imports[:i], imports[i+1:]...)
+		return true
+	}
+
+	return false
+}
+
+// refResolver resolves the contribution descriptors of the refs used in an app
+type refResolver struct {
+	project    common.AppProject
+	appImports *util.AppImports
+}
+
+// descriptor returns the descriptor of the contribution referred to, or nil if it can't be determined
+func (r *refResolver) descriptor(ref, contribType string) *util.AIflowContribDescriptor {
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" || r.appImports == nil {
+		return nil
+	}
+
+	if ref[0] == '#' {
+		details, err := findAliasedImport(r.appImports, ref[1:], contribType)
+		if err != nil {
+			return nil
+		}
+		return details.ContribDesc
+	}
+
+	refImport, err := util.ParseImport(ref)
+	if err != nil {
+		return nil
+	}
+
+	desc, err := util.GetContribDescriptorFromImport(r.project.DepManager(), refImport)
+	if err != nil {
+		return nil
+	}
+
+	return desc
+}
+
+// contribName returns the name of the contribution from its descriptor, or the ref if it can't be determined
+func (r *refResolver) contribName(ref, contribType string) string {
+	desc := r.descriptor(ref, contribType)
+	if desc == nil || desc.Name == "" {
+		return strings.TrimSpace(ref)
+	}
+
+	return desc.Name
+}
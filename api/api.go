@@ -1,18 +1,36 @@
 package api
 
 import (
-	"github.com/r2d2-ai/aiflow-cli/util"
-)
+	"os/exec"
 
-var verbose = false
+	"github.com/r2d2-ai/aiflow-cli/common"
+)
 
+// SetVerbose sets the verbosity of the default session
 func SetVerbose(enable bool) {
-	verbose = enable
-	util.SetVerbose(enable)
+	common.SetVerbose(enable)
 }
 
 func Verbose() bool {
-	return verbose
+	return common.Verbose()
 }
 
-//TODO use a logger like struct for API that can be used to log or console output
+// logf logs the message with the session of the project
+func logf(project common.AppProject, format string, args ...interface{}) {
+	common.ProjectSession(project).Logf(format, args...)
+}
+
+// printf prints the message to the output of the session of the project
+func printf(project common.AppProject, format string, args ...interface{}) {
+	common.ProjectSession(project).Printf(format, args...)
+}
+
+// eprintf prints the warning or error to the error output of the session of the project
+func eprintf(project common.AppProject, format string, args ...interface{}) {
+	common.ProjectSession(project).ErrPrintf(format, args...)
+}
+
+// execCmd runs the command in the directory with the environment and output of the session of the project
+func execCmd(project common.AppProject, cmd *exec.Cmd, dir string) error {
+	return common.ProjectSession(project).ExecCmd(cmd, dir)
+}
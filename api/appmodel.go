@@ -0,0 +1,424 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	// descriptors without an appModel predate 1.0.0, before 0.9.0 the handlers refer to shared actions by id
+	appModel05 = "0.5.0"
+	appModel09 = "0.9.0"
+	appModel10 = "1.0.0"
+)
+
+// appModelMigration transforms a descriptor of the app model 'from' to the app model 'to',
+// the problems that must be fixed manually are returned as warnings
+type appModelMigration struct {
+	from        string
+	to          string
+	description string
+	migrate     func(appObj map[string]interface{}) ([]string, error)
+}
+
+// the migrations are ordered, each one starts from the app model produced by the previous one
+var appModelMigrations = []*appModelMigration{
+	{from: appModel05, to: appModel09, description: "inline the handler actions and move the flows to resources", migrate: migrateAppModel05},
+	{from: appModel09, to: appModel10, description: "replace the action data and legacy mappings with settings, input and output", migrate: migrateAppModel09},
+}
+
+// the minimum core version supporting each app model
+var appModelCoreVersions = []struct {
+	model string
+	core  string
+}{
+	{appModel09, "0.0.0"},
+	{appModel10, "0.1.0"},
+}
+
+// AppModelStep is a migration step applied to a descriptor
+type AppModelStep struct {
+	From        string
+	To          string
+	Description string
+	Warnings    []string
+}
+
+// DetectAppModel returns the app model of a descriptor
+func DetectAppModel(appObj map[string]interface{}) string {
+
+	if model, ok := appObj["appModel"].(string); ok && model != "" {
+		return model
+	}
+
+	if triggers, ok := appObj["triggers"].([]interface{}); ok {
+		for _, trg := range triggers {
+			for _, handler := range getObjects(trg, "handlers") {
+				if _, ok := handler["actionId"]; ok {
+					return appModel05
+				}
+			}
+		}
+	}
+
+	return appModel09
+}
+
+// MigrateAppModel applies the migrations bringing the descriptor to the target app model
+func MigrateAppModel(appObj map[string]interface{}, targetModel string) ([]*AppModelStep, error) {
+
+	model := DetectAppModel(appObj)
+
+	current, err := semver.NewVersion(model)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported app model '%s'", model)
+	}
+	target, err := semver.NewVersion(targetModel)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported app model '%s'", targetModel)
+	}
+
+	if target.LessThan(*current) {
+		return nil, fmt.Errorf("app model %s is newer than the app model %s supported by the core library, upgrade the core library", model, targetModel)
+	}
+
+	var steps []*AppModelStep
+	for _, migration := range appModelMigrations {
+		if !current.Equal(*semver.New(migration.from)) {
+			continue
+		}
+		if target.LessThan(*semver.New(migration.to)) {
+			break
+		}
+
+		warnings, err := migration.migrate(appObj)
+		if err != nil {
+			return steps, fmt.Errorf("unable to migrate app model %s to %s: %v", migration.from, migration.to, err)
+		}
+		appObj["appModel"] = migration.to
+
+		steps = append(steps, &AppModelStep{From: migration.from, To: migration.to, Description: migration.description, Warnings: warnings})
+		current = semver.New(migration.to)
+	}
+
+	if !current.Equal(*target) {
+		return steps, fmt.Errorf("no migration found from app model %s to %s", current, targetModel)
+	}
+
+	return steps, nil
+}
+
+// MigrateProjectAppModel migrates AIflow.json to the latest app model supported by the installed core library,
+// with dryRun the migration steps are only reported
+func MigrateProjectAppModel(project common.AppProject, dryRun bool) error {
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	model := DetectAppModel(appObj)
+
+	steps, err := MigrateAppModel(appObj, supportedAppModel(project.DepManager()))
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		printf(project, "App model %s is up to date\n", model)
+		return nil
+	}
+
+	printAppModelSteps(common.ProjectSession(project), steps, dryRun)
+
+	if dryRun {
+		return nil
+	}
+
+	return writeAppDescriptorMap(project, appObj)
+}
+
+// migrateAppJsonModel migrates an app descriptor to the latest app model supported by the core library,
+// the descriptor is returned unchanged when it is up to date
+func migrateAppJsonModel(session *common.Session, dm util.DepManager, appJson string) (string, error) {
+
+	var appObj map[string]interface{}
+	err := json.Unmarshal([]byte(appJson), &appObj)
+	if err != nil {
+		return "", err
+	}
+
+	steps, err := MigrateAppModel(appObj, supportedAppModel(dm))
+	if err != nil || len(steps) == 0 {
+		return appJson, err
+	}
+
+	printAppModelSteps(session, steps, false)
+
+	migrated, err := json.MarshalIndent(appObj, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(migrated), nil
+}
+
+func printAppModelSteps(session *common.Session, steps []*AppModelStep, dryRun bool) {
+
+	action := "Migrated"
+	if dryRun {
+		action = "Would migrate"
+	}
+
+	for _, step := range steps {
+		session.Printf("%s app model %s to %s: %s\n", action, step.From, step.To, step.Description)
+		for _, warning := range step.Warnings {
+			session.Printf("  warning: %s\n", warning)
+		}
+	}
+}
+
+// supportedAppModel returns the latest app model supported by the core library of the project, the latest known
+// app model is assumed when the core version can't be determined
+func supportedAppModel(dm util.DepManager) string {
+
+	latest := appModelMigrations[len(appModelMigrations)-1].to
+
+	imports, err := dm.GetAllImports()
+	if err != nil {
+		return latest
+	}
+
+	core, ok := imports[AIflowCoreRepo]
+	if !ok || core.Version() == "" {
+		return latest
+	}
+
+	coreVersion, err := semver.NewVersion(strings.TrimPrefix(core.Version(), "v"))
+	if err != nil {
+		return latest
+	}
+
+	supported := ""
+	for _, model := range appModelCoreVersions {
+		if !coreVersion.LessThan(*semver.New(model.core)) {
+			supported = model.model
+		}
+	}
+
+	// pseudo-versions of the core library aren't comparable
+	if supported == "" {
+		return latest
+	}
+
+	return supported
+}
+
+// migrateAppModel05 inlines the shared actions referred to by the 'actionId' of the handlers, the flows of the
+// flow actions are moved to resources and the flow tasks are converted to activity configs
+func migrateAppModel05(appObj map[string]interface{}) ([]string, error) {
+
+	actions := make(map[string]map[string]interface{})
+	for _, action := range getObjects(appObj, "actions") {
+		if id, ok := action["id"].(string); ok {
+			actions[id] = action
+		}
+	}
+
+	resources, _ := appObj["resources"].([]interface{})
+	moved := make(map[string]bool)
+	inlined := make(map[string]bool)
+
+	triggers, _ := appObj["triggers"].([]interface{})
+	for _, trg := range triggers {
+		for _, handler := range getObjects(trg, "handlers") {
+			actionId, ok := handler["actionId"].(string)
+			if !ok {
+				continue
+			}
+
+			action, ok := actions[actionId]
+			if !ok {
+				return nil, fmt.Errorf("handler refers to unknown action '%s'", actionId)
+			}
+
+			handlerAction := map[string]interface{}{"ref": action["ref"]}
+
+			data, _ := action["data"].(map[string]interface{})
+			if flow, ok := data["flow"].(map[string]interface{}); ok {
+				resourceId := "flow:" + actionId
+				if !moved[actionId] {
+					resources = append(resources, map[string]interface{}{"id": resourceId, "data": migrateFlow05(flow)})
+					moved[actionId] = true
+				}
+				handlerAction["data"] = map[string]interface{}{"flowURI": "res://" + resourceId}
+			} else if data != nil {
+				handlerAction["data"] = data
+			}
+
+			if mappings, ok := handler["actionMappings"]; ok {
+				handlerAction["mappings"] = mappings
+				delete(handler, "actionMappings")
+			}
+
+			handler["action"] = handlerAction
+			delete(handler, "actionId")
+			inlined[actionId] = true
+		}
+	}
+
+	// the actions are inlined in the handlers, only the actions that aren't referred to are kept
+	var remaining []interface{}
+	for _, action := range getObjects(appObj, "actions") {
+		if id, ok := action["id"].(string); !ok || !inlined[id] {
+			remaining = append(remaining, action)
+		}
+	}
+	if len(remaining) > 0 {
+		appObj["actions"] = remaining
+	} else {
+		delete(appObj, "actions")
+	}
+
+	if len(resources) > 0 {
+		appObj["resources"] = resources
+	}
+
+	return nil, nil
+}
+
+// migrateFlow05 flattens the root task of a flow and converts its tasks
+func migrateFlow05(flow map[string]interface{}) map[string]interface{} {
+
+	migrated := make(map[string]interface{})
+	for key, val := range flow {
+		switch key {
+		case "type", "rootTask", "errorHandlerTask":
+		default:
+			migrated[key] = val
+		}
+	}
+
+	if rootTask, ok := flow["rootTask"].(map[string]interface{}); ok {
+		migrated["tasks"] = migrateTasks05(rootTask["tasks"])
+		migrated["links"] = migrateLinks05(rootTask["links"])
+	}
+
+	if errorHandler, ok := flow["errorHandlerTask"].(map[string]interface{}); ok {
+		migrated["errorHandler"] = map[string]interface{}{
+			"tasks": migrateTasks05(errorHandler["tasks"]),
+			"links": migrateLinks05(errorHandler["links"]),
+		}
+	}
+
+	return migrated
+}
+
+func migrateTasks05(tasks interface{}) []interface{} {
+
+	migrated := []interface{}{}
+	for _, task := range toObjects(tasks) {
+
+		activity := map[string]interface{}{"ref": task["activityRef"]}
+
+		input := make(map[string]interface{})
+		for _, attr := range getObjects(task, "attributes") {
+			if name, ok := attr["name"].(string); ok {
+				input[name] = attr["value"]
+			}
+		}
+		if len(input) > 0 {
+			activity["input"] = input
+		}
+
+		if mappings, ok := task["inputMappings"]; ok {
+			activity["mappings"] = map[string]interface{}{"input": mappings}
+		}
+
+		migratedTask := make(map[string]interface{})
+		for key, val := range task {
+			switch key {
+			case "type", "activityType", "activityRef", "attributes", "inputMappings":
+			default:
+				migratedTask[key] = val
+			}
+		}
+		migratedTask["id"] = fmt.Sprintf("%v", task["id"])
+		migratedTask["activity"] = activity
+
+		migrated = append(migrated, migratedTask)
+	}
+
+	return migrated
+}
+
+func migrateLinks05(links interface{}) []interface{} {
+
+	migrated := []interface{}{}
+	for _, link := range toObjects(links) {
+		link["from"] = fmt.Sprintf("%v", link["from"])
+		link["to"] = fmt.Sprintf("%v", link["to"])
+		migrated = append(migrated, link)
+	}
+
+	return migrated
+}
+
+// migrateAppModel09 replaces the 'data' of the actions with 'settings' and converts the legacy mappings
+// of the actions and activities to 'input' and 'output'
+func migrateAppModel09(appObj map[string]interface{}) ([]string, error) {
+
+	var warnings []string
+
+	util.WalkAppContribConfigs(appObj, func(config map[string]interface{}, contribType string) {
+
+		if data, ok := config["data"].(map[string]interface{}); ok && contribType == "action" {
+			settings, ok := config["settings"].(map[string]interface{})
+			if !ok {
+				settings = make(map[string]interface{})
+			}
+			for key, val := range data {
+				settings[key] = val
+			}
+			config["settings"] = settings
+			delete(config, "data")
+		}
+
+		if mappings, ok := config["mappings"].(map[string]interface{}); ok {
+			ref, _ := config["ref"].(string)
+			warnings = append(warnings, convertLegacyMappings(config, mappings, ref)...)
+		}
+	})
+
+	return warnings, nil
+}
+
+// getObjects returns the objects of an array of an object
+func getObjects(obj interface{}, key string) []map[string]interface{} {
+
+	objMap, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	return toObjects(objMap[key])
+}
+
+func toObjects(val interface{}) []map[string]interface{} {
+
+	items, _ := val.([]interface{})
+
+	var objects []map[string]interface{}
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, itemMap)
+		}
+	}
+
+	return objects
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var appModel05Json = `{
+  "name": "legacy",
+  "type": "flogo:app",
+  "version": "0.0.1",
+  "triggers": [
+    {
+      "id": "receive_http_message",
+      "ref": "github.com/TIBCOSoftware/flogo-contrib/trigger/rest",
+      "handlers": [
+        {
+          "actionId": "my_flow",
+          "settings": { "method": "GET", "path": "/test" },
+          "actionMappings": {
+            "input": [ { "type": 1, "value": "$.pathParams", "mapTo": "params" } ],
+            "output": [ { "type": 1, "value": "$.code", "mapTo": "code" } ]
+          }
+        }
+      ]
+    }
+  ],
+  "actions": [
+    {
+      "id": "my_flow",
+      "ref": "github.com/TIBCOSoftware/flogo-contrib/action/flow",
+      "data": {
+        "flow": {
+          "name": "My Flow",
+          "type": 1,
+          "rootTask": {
+            "id": 1,
+            "type": 1,
+            "tasks": [
+              {
+                "id": 2,
+                "name": "Log Message",
+                "type": 1,
+                "activityType": "tibco-activity-log",
+                "activityRef": "github.com/TIBCOSoftware/flogo-contrib/activity/log",
+                "attributes": [ { "name": "flowInfo", "value": "true", "type": "boolean" } ],
+                "inputMappings": [ { "type": 1, "value": "$.params", "mapTo": "message" } ]
+              },
+              {
+                "id": 3,
+                "name": "Reply",
+                "activityRef": "github.com/TIBCOSoftware/flogo-contrib/activity/actreply",
+                "attributes": [ { "name": "mappings", "value": [] } ]
+              }
+            ],
+            "links": [ { "id": 1, "from": 2, "to": 3, "type": 0 } ]
+          }
+        }
+      }
+    },
+    {
+      "id": "unused",
+      "ref": "github.com/TIBCOSoftware/flogo-contrib/action/flow",
+      "data": {}
+    }
+  ]
+}`
+
+var appModel09Json = `{
+  "name": "legacy",
+  "type": "flogo:app",
+  "version": "0.0.1",
+  "triggers": [
+    {
+      "id": "receive_http_message",
+      "ref": "github.com/TIBCOSoftware/flogo-contrib/trigger/rest",
+      "handlers": [
+        {
+          "settings": { "method": "GET", "path": "/test" },
+          "action": {
+            "ref": "github.com/TIBCOSoftware/flogo-contrib/action/flow",
+            "data": { "flowURI": "res://flow:my_flow" },
+            "mappings": {
+              "input": [ { "type": "assign", "value": "$.pathParams", "mapTo": "params" } ],
+              "output": [ { "type": "literal", "value": 200, "mapTo": "code" } ]
+            }
+          }
+        }
+      ]
+    }
+  ],
+  "resources": [
+    {
+      "id": "flow:my_flow",
+      "data": {
+        "tasks": [
+          {
+            "id": "log_2",
+            "activity": {
+              "ref": "github.com/TIBCOSoftware/flogo-contrib/activity/log",
+              "input": { "flowInfo": "true" },
+              "mappings": {
+                "input": [
+                  { "type": 1, "value": "$flow.params", "mapTo": "message" },
+                  { "type": "unknown", "value": "x", "mapTo": "other" }
+                ]
+              }
+            }
+          }
+        ]
+      }
+    }
+  ]
+}`
+
+func parseTestApp(t *testing.T, appJson string) map[string]interface{} {
+	var appObj map[string]interface{}
+	err := json.Unmarshal([]byte(appJson), &appObj)
+	assert.Nil(t, err)
+	return appObj
+}
+
+func TestDetectAppModel(t *testing.T) {
+
+	assert.Equal(t, appModel05, DetectAppModel(parseTestApp(t, appModel05Json)))
+	assert.Equal(t, appModel09, DetectAppModel(parseTestApp(t, appModel09Json)))
+	assert.Equal(t, appModel10, DetectAppModel(parseTestApp(t, newJsonString)))
+}
+
+func TestMigrateAppModel05(t *testing.T) {
+
+	appObj := parseTestApp(t, appModel05Json)
+
+	warnings, err := migrateAppModel05(appObj)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+
+	handler := getObjects(appObj["triggers"].([]interface{})[0], "handlers")[0]
+	assert.NotContains(t, handler, "actionId")
+	assert.NotContains(t, handler, "actionMappings")
+
+	action := handler["action"].(map[string]interface{})
+	assert.Equal(t, "github.com/TIBCOSoftware/flogo-contrib/action/flow", action["ref"])
+	assert.Equal(t, map[string]interface{}{"flowURI": "res://flow:my_flow"}, action["data"])
+	assert.Contains(t, action["mappings"], "input")
+
+	actions := getObjects(appObj, "actions")
+	assert.Len(t, actions, 1)
+	assert.Equal(t, "unused", actions[0]["id"])
+
+	resources := getObjects(appObj, "resources")
+	assert.Len(t, resources, 1)
+	assert.Equal(t, "flow:my_flow", resources[0]["id"])
+
+	flow := resources[0]["data"].(map[string]interface{})
+	assert.Equal(t, "My Flow", flow["name"])
+	assert.NotContains(t, flow, "rootTask")
+
+	tasks := getObjects(flow, "tasks")
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "2", tasks[0]["id"])
+	assert.Equal(t, "Log Message", tasks[0]["name"])
+	assert.NotContains(t, tasks[0], "activityRef")
+
+	activity := tasks[0]["activity"].(map[string]interface{})
+	assert.Equal(t, "github.com/TIBCOSoftware/flogo-contrib/activity/log", activity["ref"])
+	assert.Equal(t, map[string]interface{}{"flowInfo": "true"}, activity["input"])
+	assert.Contains(t, activity["mappings"], "input")
+
+	links := getObjects(flow, "links")
+	assert.Equal(t, "2", links[0]["from"])
+	assert.Equal(t, "3", links[0]["to"])
+}
+
+func TestMigrateAppModel09(t *testing.T) {
+
+	appObj := parseTestApp(t, appModel09Json)
+
+	warnings, err := migrateAppModel09(appObj)
+	assert.Nil(t, err)
+	assert.Len(t, warnings, 1)
+
+	handler := getObjects(appObj["triggers"].([]interface{})[0], "handlers")[0]
+	action := handler["action"].(map[string]interface{})
+	assert.NotContains(t, action, "data")
+	assert.NotContains(t, action, "mappings")
+	assert.Equal(t, map[string]interface{}{"flowURI": "res://flow:my_flow"}, action["settings"])
+	assert.Equal(t, map[string]interface{}{"params": "=$.pathParams"}, action["input"])
+	assert.Equal(t, map[string]interface{}{"code": float64(200)}, action["output"])
+
+	task := getObjects(getObjects(appObj, "resources")[0]["data"], "tasks")[0]
+	activity := task["activity"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"flowInfo": "true", "message": "=$flow.params"}, activity["input"])
+	assert.Len(t, activity["mappings"].(map[string]interface{})["input"], 1)
+}
+
+func TestMigrateAppModel(t *testing.T) {
+
+	appObj := parseTestApp(t, appModel05Json)
+
+	steps, err := MigrateAppModel(appObj, appModel10)
+	assert.Nil(t, err)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, appModel10, appObj["appModel"])
+	assert.Equal(t, appModel10, DetectAppModel(appObj))
+
+	steps, err = MigrateAppModel(appObj, appModel10)
+	assert.Nil(t, err)
+	assert.Empty(t, steps)
+
+	appObj = parseTestApp(t, appModel05Json)
+	steps, err = MigrateAppModel(appObj, appModel09)
+	assert.Nil(t, err)
+	assert.Len(t, steps, 1)
+	assert.Equal(t, appModel09, appObj["appModel"])
+
+	appObj = parseTestApp(t, newJsonString)
+	_, err = MigrateAppModel(appObj, appModel09)
+	assert.NotNil(t, err)
+
+	appObj["appModel"] = "0.7.0"
+	_, err = MigrateAppModel(appObj, appModel10)
+	assert.NotNil(t, err)
+}
+
+func TestSupportedAppModel(t *testing.T) {
+
+	srcDir, err := ioutil.TempDir("", "appmodel")
+	assert.Nil(t, err)
+	defer os.RemoveAll(srcDir)
+
+	for core, model := range map[string]string{"": appModel10, "v0.0.5": appModel09, "v0.1.0": appModel10, "v1.2.3": appModel10} {
+		goMod := "module main\n"
+		if core != "" {
+			goMod += "\nrequire (\n\t" + AIflowCoreRepo + " " + core + "\n)\n"
+		}
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(srcDir, "go.mod"), []byte(goMod), 0644))
+
+		assert.Equal(t, model, supportedAppModel(util.NewDepManager(srcDir)), "core %s", core)
+	}
+}
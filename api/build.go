@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
 	"github.com/r2d2-ai/aiflow-cli/util"
@@ -18,137 +19,234 @@ const (
 	fileEmbeddedAppGo string = "embeddedapp.go"
 )
 
-func BuildProject(project common.AppProject, options common.BuildOptions) error {
+// BuildProject builds the project's app and returns the result of the build
+func BuildProject(project common.AppProject, options common.BuildOptions) (*common.BuildResult, error) {
 
-	err := project.DepManager().AddReplacedContribForBuild()
+	err := fireEvent(&common.BuildEvent{Phase: common.PhasePre, Project: project, Options: options})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	buildStart := time.Now()
+
+	// the pre processors run before the build is checked against the cache, the files they generate are part of its key
 	buildPreProcessors := common.BuildPreProcessors()
 
 	if len(buildPreProcessors) > 0 {
 		for _, processor := range buildPreProcessors {
 			err = processor.DoPreProcessing(project, options)
 			if err != nil {
-				return err
+				return nil, err
+			}
+		}
+	}
+
+	// shim builds aren't cached, their executables are built by the shim
+	cacheKey := ""
+	if options.Shim == "" {
+		cacheKey, err = buildCacheKey(project, options)
+		if err != nil {
+			return nil, err
+		}
+
+		if result := cachedBuild(project, cacheKey, options); result != nil && !options.Force {
+			logf(project, "Executables are up to date, skipping build\n")
+			result.Duration = time.Since(buildStart)
+
+			err = postProcessBuild(project, result)
+			if err != nil {
+				return nil, err
+			}
+
+			err = fireEvent(&common.BuildEvent{Phase: common.PhasePost, Project: project, Options: options, Executables: result.Executables, Result: result})
+			if err != nil {
+				return nil, err
 			}
+			return result, nil
 		}
 	}
 
+	err = project.DepManager().AddReplacedContribForBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	warnImportConflicts(project)
+
 	var builder common.Builder
 	embedConfig := options.EmbedConfig
 
 	if options.Shim != "" {
+		if options.Reproducible {
+			return nil, fmt.Errorf("reproducible builds aren't supported with a shim")
+		}
 		builder = &ShimBuilder{shim: options.Shim}
 		embedConfig = true
 	} else {
-		builder = &AppBuilder{}
+		builder = &AppBuilder{targets: options.Targets, reproducible: options.Reproducible}
+	}
+
+	// the overlays of an environment, compression and encryption only apply to the embedded configuration
+	if options.Env != "" || options.CompressConfig || options.EncryptConfig {
+		embedConfig = true
 	}
 
 	if embedConfig {
-		err = createEmbeddedAppGoFile(project)
+		err = createEmbeddedAppGoFile(project, options)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		err = cleanupEmbeddedAppGoFile(project)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	if options.OptimizeImports {
-		if Verbose() {
-			fmt.Println("Optimizing imports...")
-		}
-		err := optimizeImports(project)
+	// src/imports.go is only sorted and optimized for the build, it is restored afterwards
+	if options.Reproducible || options.OptimizeImports {
+		err = backupImports(project)
 		defer restoreImports(project)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	buildTime := buildStart
+	if options.Reproducible {
+		buildTime, err = sourceDateEpoch(project)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		err = sortGoImports(project)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the build information is only reported, it doesn't fail the build
+	var info *common.BuildInfo
+	if needsBuildInfo(options) {
+		info, err = collectBuildInfo(project, options.Env, buildTime)
+		if err != nil {
+			eprintf(project, "Warning: unable to collect the build information: %v\n", err)
+			info = nil
+		}
+	}
+	if options.StampInfo && info != nil {
+		err = createBuildInfoGoFile(project, info)
+		if err != nil {
+			return nil, err
 		}
+		defer cleanupBuildInfoGoFile(project)
 	}
 
-	err = builder.Build(project)
+	var removedImports []string
+	if options.OptimizeImports {
+		logf(project, "Optimizing imports...\n")
+		removedImports, err = optimizeImports(project)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var executables []string
+	if exeBuilder, ok := builder.(common.ExecutableBuilder); ok {
+		executables, err = exeBuilder.BuildExecutables(project)
+	} else {
+		err = builder.Build(project)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	buildPostProcessors := common.BuildPostProcessors()
+	result := &common.BuildResult{
+		Executables:    executables,
+		Targets:        buildTargets(project, options),
+		Options:        options,
+		Duration:       time.Since(buildStart),
+		RemovedImports: removedImports,
+		EmbeddedConfig: embedConfig,
+		Info:           info,
+	}
 
-	if len(buildPostProcessors) > 0 {
-		for _, processor := range buildPostProcessors {
-			err = processor.DoPostProcessing(project)
-			if err != nil {
-				return err
-			}
+	err = postProcessBuild(project, result)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheKey != "" {
+		err = recordBuild(project, cacheKey, result)
+		if err != nil {
+			eprintf(project, "Warning: unable to record the build in %s: %v\n", fileBuildCache, err)
 		}
 	}
 
-	return nil
+	err = fireEvent(&common.BuildEvent{Phase: common.PhasePost, Project: project, Options: options, Executables: result.Executables, Result: result})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-func cleanupEmbeddedAppGoFile(project common.AppProject) error {
-	embedSrcPath := filepath.Join(project.SrcDir(), fileEmbeddedAppGo)
+// postProcessBuild runs the registered build post processors, also when the build was skipped because the
+// executables were up to date
+func postProcessBuild(project common.AppProject, result *common.BuildResult) error {
 
-	if _, err := os.Stat(embedSrcPath); err == nil {
-		if Verbose() {
-			fmt.Println("Removing embed configuration")
+	for _, processor := range common.BuildPostProcessors() {
+		var err error
+		if resultProcessor, ok := processor.(common.BuildResultPostProcessor); ok {
+			err = resultProcessor.DoPostProcessingWithResult(project, result)
+		} else {
+			err = processor.DoPostProcessing(project)
 		}
-		err = os.Remove(embedSrcPath)
 		if err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
-func createEmbeddedAppGoFile(project common.AppProject) error {
+// needsBuildInfo returns whether the build information is stamped or may be used by the post processors or hooks
+// receiving the result of the build, collecting it resolves the contributions and runs git
+func needsBuildInfo(options common.BuildOptions) bool {
 
-	embedSrcPath := filepath.Join(project.SrcDir(), fileEmbeddedAppGo)
-
-	if Verbose() {
-		fmt.Println("Embedding configuration in application...")
+	if options.StampInfo || len(common.Hooks()) > 0 {
+		return true
 	}
 
-	buf, err := ioutil.ReadFile(filepath.Join(project.Dir(), fileAIflowJson))
-	if err != nil {
-		return err
+	for _, processor := range common.BuildPostProcessors() {
+		if _, ok := processor.(common.BuildResultPostProcessor); ok {
+			return true
+		}
 	}
-	AIflowJSON := string(buf)
 
-	tplFile := tplEmbeddedAppGoFile
-	if !isNewMain(project) {
-		tplFile = tplEmbeddedAppOldGoFile
+	return false
+}
+
+// buildTargets returns the GOOS/GOARCH the app is built for
+func buildTargets(project common.AppProject, options common.BuildOptions) []string {
+	if len(options.Targets) > 0 && options.Shim == "" {
+		return options.Targets
 	}
 
-	engineJSON := ""
+	session := common.ProjectSession(project)
+	return []string{session.GOOS() + "/" + session.GOARCH()}
+}
+
+func cleanupEmbeddedAppGoFile(project common.AppProject) error {
+	embedSrcPath := filepath.Join(project.SrcDir(), fileEmbeddedAppGo)
 
-	if util.FileExists(filepath.Join(project.Dir(), fileEngineJson)) {
-		buf, err = ioutil.ReadFile(filepath.Join(project.Dir(), fileEngineJson))
+	if _, err := os.Stat(embedSrcPath); err == nil {
+		logf(project, "Removing embed configuration\n")
+		err = os.Remove(embedSrcPath)
 		if err != nil {
 			return err
 		}
-
-		engineJSON = string(buf)
-	}
-
-	data := struct {
-		AIflowJSON string
-		EngineJSON string
-	}{
-		AIflowJSON,
-		engineJSON,
 	}
-
-	f, err := os.Create(embedSrcPath)
-	if err != nil {
-		return err
-	}
-	RenderTemplate(f, tplFile, &data)
-	_ = f.Close()
-
 	return nil
 }
 
@@ -164,32 +262,6 @@ func isNewMain(project common.AppProject) bool {
 	return false
 }
 
-var tplEmbeddedAppGoFile = `// Do not change this file, it has been generated using AIflow-cli
-// If you change it and rebuild the application your changes might get lost
-package main
-
-// embedded AIflow app descriptor file
-const AIflowJSON string = ` + "`{{.AIflowJSON}}`" + `
-const engineJSON string = ` + "`{{.EngineJSON}}`" + `
-
-func init () {
-	cfgJson = AIflowJSON
-	cfgEngine = engineJSON
-}
-`
-
-var tplEmbeddedAppOldGoFile = `// Do not change this file, it has been generated using AIflow-cli
-// If you change it and rebuild the application your changes might get lost
-package main
-
-// embedded AIflow app descriptor file
-const AIflowJSON string = ` + "`{{.AIflowJSON}}`" + `
-
-func init () {
-	cfgJson = AIflowJSON
-}
-`
-
 func initMain(project common.AppProject, backupMain bool) error {
 
 	//backup main if it exists
@@ -219,11 +291,12 @@ func initMain(project common.AppProject, backupMain bool) error {
 	return nil
 }
 
-func optimizeImports(project common.AppProject) error {
+// optimizeImports removes the unreferenced core contributions from the Go imports and returns the removed imports
+func optimizeImports(project common.AppProject) ([]string, error) {
 
 	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var unused []util.Import
@@ -235,33 +308,37 @@ func optimizeImports(project common.AppProject) error {
 	}
 
 	importsFile := filepath.Join(project.SrcDir(), fileImportsGo)
-	importsFileOrig := filepath.Join(project.SrcDir(), fileImportsGo+".orig")
-
-	err = util.CopyFile(importsFile, importsFileOrig)
-	if err != nil {
-		return err
-	}
 
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, importsFile, nil, parser.ImportsOnly)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var removed []string
 	for _, i := range unused {
-		if Verbose() {
-			fmt.Printf("  Removing Import: %s\n", i.GoImportPath())
+		logf(project, "  Removing Import: %s\n", i.GoImportPath())
+		if util.DeleteImport(fset, file, i.GoImportPath()) {
+			removed = append(removed, i.GoImportPath())
 		}
-		util.DeleteImport(fset, file, i.GoImportPath())
 	}
 
 	f, err := os.Create(importsFile)
 	defer f.Close()
 	if err := printer.Fprint(f, fset, file); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return removed, nil
+}
+
+// backupImports copies src/imports.go so that it is restored by restoreImports
+func backupImports(project common.AppProject) error {
+
+	importsFile := filepath.Join(project.SrcDir(), fileImportsGo)
+	importsFileOrig := filepath.Join(project.SrcDir(), fileImportsGo+".orig")
+
+	return util.CopyFile(importsFile, importsFileOrig)
 }
 
 func restoreImports(project common.AppProject) {
@@ -272,14 +349,14 @@ func restoreImports(project common.AppProject) {
 	if _, err := os.Stat(importsFileOrig); err == nil {
 		err = util.CopyFile(importsFileOrig, importsFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error restoring imports file '%s': %v\n", importsFile, err)
+			eprintf(project, "Error restoring imports file '%s': %v\n", importsFile, err)
 			return
 		}
 
 		var err = os.Remove(importsFileOrig)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error removing backup imports file '%s': %v\n", importsFileOrig, err)
-			fmt.Fprintf(os.Stderr, "Manually remove backup imports file '%s'\n", importsFileOrig)
+			eprintf(project, "Error removing backup imports file '%s': %v\n", importsFileOrig, err)
+			eprintf(project, "Manually remove backup imports file '%s'\n", importsFileOrig)
 		}
 	}
 }
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	fileBuildCache = ".aiflow-build.json"
+)
+
+// environment variables of the go command that change the executables built
+var buildCacheEnvVars = []string{"GOOS", "GOARCH", "GOARM", "CGO_ENABLED", "GOFLAGS", "GOEXPERIMENT", envSourceDateEpoch}
+
+// buildCache is the metadata of the last build, recorded in the bin directory
+type buildCache struct {
+	Key            string            `json:"key"`
+	Executables    map[string]string `json:"executables"` // sha256 of the executables, by name
+	Targets        []string          `json:"targets,omitempty"`
+	RemovedImports []string          `json:"removedImports,omitempty"`
+	EmbeddedConfig bool              `json:"embeddedConfig"`
+	Info           *common.BuildInfo `json:"info,omitempty"`
+}
+
+// cachedBuild returns the result of the last build if it was built with the key and its executables are unchanged
+func cachedBuild(project common.AppProject, key string, options common.BuildOptions) *common.BuildResult {
+
+	buf, err := ioutil.ReadFile(filepath.Join(project.BinDir(), fileBuildCache))
+	if err != nil {
+		return nil
+	}
+
+	var cache buildCache
+	if err := json.Unmarshal(buf, &cache); err != nil || cache.Key != key || len(cache.Executables) == 0 {
+		return nil
+	}
+
+	var executables []string
+	for name, sha := range cache.Executables {
+		exe := filepath.Join(project.BinDir(), name)
+		if actual, err := hashFile(exe); err != nil || actual != sha {
+			return nil
+		}
+		executables = append(executables, exe)
+	}
+	sort.Strings(executables)
+
+	return &common.BuildResult{
+		Executables:    executables,
+		Targets:        cache.Targets,
+		Options:        options,
+		RemovedImports: cache.RemovedImports,
+		EmbeddedConfig: cache.EmbeddedConfig,
+		Info:           cache.Info,
+		Cached:         true,
+	}
+}
+
+// recordBuild records the key and executables of the build in the bin directory
+func recordBuild(project common.AppProject, key string, result *common.BuildResult) error {
+
+	cache := &buildCache{
+		Key:            key,
+		Executables:    make(map[string]string),
+		Targets:        result.Targets,
+		RemovedImports: result.RemovedImports,
+		EmbeddedConfig: result.EmbeddedConfig,
+		Info:           result.Info,
+	}
+
+	for _, exe := range result.Executables {
+		sha, err := hashFile(exe)
+		if err != nil {
+			return err
+		}
+		cache.Executables[filepath.Base(exe)] = sha
+	}
+
+	buf, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(project.BinDir(), fileBuildCache), buf, 0644)
+}
+
+// buildCacheKey returns the hash of the inputs of the build: the descriptors and their overlays, the Go files of
+// src, except those generated by the build, go.mod and go.sum, the trees of the locally replaced modules, the
+// options, the version and environment of the go command, the git revision and the CLI version
+func buildCacheKey(project common.AppProject, options common.BuildOptions) (string, error) {
+
+	h := sha256.New()
+	session := common.ProjectSession(project)
+
+	// forcing the build doesn't change the executables
+	options.Force = false
+	optionsJson, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "options %s\n", optionsJson)
+	fmt.Fprintf(h, "cli %s\n", common.CLIVersion())
+	fmt.Fprintf(h, "go %s\n", goToolchainVersion(session))
+
+	revision, modified := vcsRevision(project)
+	fmt.Fprintf(h, "revision %s %t\n", revision, modified)
+
+	for _, envVar := range buildCacheEnvVars {
+		fmt.Fprintf(h, "env %s=%s\n", envVar, session.Getenv(envVar))
+	}
+
+	if options.EncryptConfig {
+		// the configuration is encrypted again if the key changes
+		key, err := embeddedConfigKey(session)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "key %x\n", sha256.Sum256(key))
+	}
+
+	files := []string{filepath.Join(project.Dir(), fileAIflowJson), filepath.Join(project.Dir(), fileEngineJson)}
+	if options.Env != "" {
+		for _, overlay := range []string{appOverlayFile(options.Env), propertiesOverlayFile(options.Env), engineOverlayFile(options.Env)} {
+			files = append(files, filepath.Join(project.Dir(), overlay))
+		}
+	}
+	files = append(files, filepath.Join(project.SrcDir(), "go.mod"), filepath.Join(project.SrcDir(), "go.sum"))
+
+	for _, file := range files {
+		if err := hashInputFile(h, project.Dir(), file); err != nil {
+			return "", err
+		}
+	}
+
+	err = hashTree(h, project.Dir(), project.SrcDir(), func(path string) bool {
+		name := filepath.Base(path)
+		return strings.HasSuffix(name, ".go") && name != fileEmbeddedAppGo && name != fileBuildInfoGo
+	})
+	if err != nil {
+		return "", err
+	}
+
+	replaceDirs, err := localReplaceDirs(filepath.Join(project.SrcDir(), "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range replaceDirs {
+		err = hashTree(h, dir, dir, func(string) bool { return true })
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// goToolchainVersion returns the version of the go command of the session, empty if it can't be determined
+func goToolchainVersion(session *common.Session) string {
+
+	cmd := exec.Command("go", "env", "GOVERSION")
+	cmd.Env = session.Environ()
+	if out, err := cmd.Output(); err == nil && strings.TrimSpace(string(out)) != "" {
+		return strings.TrimSpace(string(out))
+	}
+
+	// GOVERSION is only available since go 1.16
+	cmd = exec.Command("go", "version")
+	cmd.Env = session.Environ()
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return parseGoVersionOutput(string(out))
+}
+
+// hashInputFile adds the name and content of the file to the hash, a missing file is recorded as such
+func hashInputFile(h hash.Hash, baseDir, path string) error {
+
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		rel = path
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(h, "missing %s\n", filepath.ToSlash(rel))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(h, "file %s\n", filepath.ToSlash(rel))
+	_, err = io.Copy(h, f)
+
+	return err
+}
+
+// hashTree adds the files of the directory tree accepted by the filter to the hash, VCS directories are skipped
+func hashTree(h hash.Hash, baseDir, dir string, accept func(path string) bool) error {
+
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != dir && (name == ".git" || name == ".hg" || name == ".svn") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if accept(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := hashInputFile(h, baseDir, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// localReplaceDirs returns the directories of the modules replaced by a local path in go.mod
+func localReplaceDirs(goModFile string) ([]string, error) {
+
+	f, err := os.Open(goModFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dirs []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		switch {
+		case line == "replace (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "replace "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "replace "))
+		case !inBlock:
+			continue
+		}
+
+		parts := strings.SplitN(line, "=>", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		// a local path replacement has no version
+		target := strings.Fields(parts[1])
+		if len(target) != 1 || !(strings.HasPrefix(target[0], ".") || filepath.IsAbs(target[0])) {
+			continue
+		}
+
+		dir := target[0]
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(goModFile), dir)
+		}
+		if util.DirExists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs, scanner.Err()
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBuildCacheTestProject(t *testing.T) (string, common.AppProject) {
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+
+	for _, dir := range []string{dirSrc, "bin", "contrib"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(tempDir, dir), os.ModePerm))
+	}
+
+	files := map[string]string{
+		fileAIflowJson:                     `{"name": "app"}`,
+		filepath.Join(dirSrc, fileMainGo):  "package main\n",
+		filepath.Join(dirSrc, "go.mod"):    "module app\n\nreplace github.com/r2d2-ai/contrib => ../contrib\nreplace github.com/r2d2-ai/other => github.com/r2d2-ai/fork v1.0.0\n",
+		filepath.Join("contrib", "log.go"): "package log\n",
+	}
+	for file, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644))
+	}
+
+	return tempDir, NewAppProject(tempDir)
+}
+
+func TestLocalReplaceDirs(t *testing.T) {
+
+	tempDir, project := newBuildCacheTestProject(t)
+	defer os.RemoveAll(tempDir)
+
+	dirs, err := localReplaceDirs(filepath.Join(project.SrcDir(), "go.mod"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "contrib")}, dirs)
+}
+
+func TestBuildCacheKey(t *testing.T) {
+
+	tempDir, project := newBuildCacheTestProject(t)
+	defer os.RemoveAll(tempDir)
+
+	key, err := buildCacheKey(project, common.BuildOptions{})
+	assert.Nil(t, err)
+
+	forcedKey, err := buildCacheKey(project, common.BuildOptions{Force: true})
+	assert.Nil(t, err)
+	assert.Equal(t, key, forcedKey)
+
+	embedKey, err := buildCacheKey(project, common.BuildOptions{EmbedConfig: true})
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, embedKey)
+
+	// generated files aren't inputs of the build
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(project.SrcDir(), fileBuildInfoGo), []byte("package main\n"), 0644))
+	generatedKey, err := buildCacheKey(project, common.BuildOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, key, generatedKey)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, "contrib", "log.go"), []byte("package log\n\nfunc init() {}\n"), 0644))
+	replacedKey, err := buildCacheKey(project, common.BuildOptions{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, replacedKey)
+
+	// the executables change with the go toolchain
+	assert.NotEqual(t, "", goToolchainVersion(common.ProjectSession(project)))
+}
+
+func TestCachedBuild(t *testing.T) {
+
+	tempDir, project := newBuildCacheTestProject(t)
+	defer os.RemoveAll(tempDir)
+
+	exe := filepath.Join(project.BinDir(), "app")
+	assert.Nil(t, ioutil.WriteFile(exe, []byte("executable"), 0755))
+
+	assert.Nil(t, cachedBuild(project, "key", common.BuildOptions{}))
+
+	err := recordBuild(project, "key", &common.BuildResult{Executables: []string{exe}, EmbeddedConfig: true})
+	assert.Nil(t, err)
+
+	result := cachedBuild(project, "key", common.BuildOptions{})
+	assert.NotNil(t, result)
+	assert.True(t, result.Cached)
+	assert.True(t, result.EmbeddedConfig)
+	assert.Equal(t, []string{exe}, result.Executables)
+
+	assert.Nil(t, cachedBuild(project, "other", common.BuildOptions{}))
+
+	assert.Nil(t, ioutil.WriteFile(exe, []byte("modified"), 0755))
+	assert.Nil(t, cachedBuild(project, "key", common.BuildOptions{}))
+}
+
+type countingProcessor struct {
+	pre, post int
+}
+
+func (p *countingProcessor) DoPreProcessing(project common.AppProject, options common.BuildOptions) error {
+	p.pre++
+	return nil
+}
+
+func (p *countingProcessor) DoPostProcessing(project common.AppProject) error {
+	p.post++
+	return nil
+}
+
+func TestBuildProjectCached(t *testing.T) {
+
+	tempDir, project := newBuildCacheTestProject(t)
+	defer os.RemoveAll(tempDir)
+
+	processor := &countingProcessor{}
+	common.RegisterBuildPreProcessor(processor)
+	common.RegisterBuildPostProcessor(processor)
+
+	exe := filepath.Join(project.BinDir(), "app")
+	assert.Nil(t, ioutil.WriteFile(exe, []byte("executable"), 0755))
+
+	key, err := buildCacheKey(project, common.BuildOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, recordBuild(project, key, &common.BuildResult{Executables: []string{exe}}))
+
+	// the processors run even if the build is skipped
+	result, err := BuildProject(project, common.BuildOptions{})
+	assert.Nil(t, err)
+	assert.True(t, result.Cached)
+	assert.Equal(t, 1, processor.pre)
+	assert.Equal(t, 1, processor.post)
+}
@@ -4,49 +4,121 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
-	"github.com/r2d2-ai/aiflow-cli/util"
 )
 
 type AppBuilder struct {
+	targets      []string
+	reproducible bool
 }
 
-func (*AppBuilder) Build(project common.AppProject) error {
+func (ab *AppBuilder) Build(project common.AppProject) error {
+	_, err := ab.BuildExecutables(project)
+	return err
+}
+
+// BuildExecutables builds the app, for each target if any, and returns the executables built
+func (ab *AppBuilder) BuildExecutables(project common.AppProject) ([]string, error) {
 
 	err := restoreMain(project)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if len(ab.targets) > 0 {
+		var executables []string
+		for _, target := range ab.targets {
+			exe, err := targetGoBuild(project, target, ab.reproducible)
+			if err != nil {
+				return nil, err
+			}
+			executables = append(executables, exe)
+		}
+		return executables, nil
+	}
+
+	exe, err := simpleGoBuild(project, ab.reproducible)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{exe}, nil
+}
+
+// targetGoBuild builds the app for a GOOS/GOARCH target, the executable is suffixed with the target
+func targetGoBuild(project common.AppProject, target string, reproducible bool) (string, error) {
+
+	parts := strings.Split(target, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid build target '%s', expected GOOS/GOARCH", target)
+	}
+	goos, goarch := parts[0], parts[1]
+
+	err := os.MkdirAll(project.BinDir(), os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	exe := filepath.Join(project.BinDir(), project.Name()+"-"+goos+"-"+goarch)
+	if goos == "windows" {
+		exe = exe + ".exe"
 	}
 
-	err = simpleGoBuild(project)
+	logf(project, "Performing 'go build' for %s...\n", target)
+
+	cmd := goBuildCmd(project, exe, reproducible)
+	cmd.Env = append(cmd.Env, "GOOS="+goos, "GOARCH="+goarch)
+
+	err = execCmd(project, cmd, project.SrcDir())
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return exe, nil
 }
 
-func simpleGoBuild(project common.AppProject) error {
+// simpleGoBuild builds the executable of the project and returns its path
+func simpleGoBuild(project common.AppProject, reproducible bool) (string, error) {
 	if _, err := os.Stat(project.BinDir()); err != nil {
-		if Verbose() {
-			fmt.Println("Creating 'bin' directory")
-		}
+		logf(project, "Creating 'bin' directory\n")
 		err = os.MkdirAll(project.BinDir(), os.ModePerm)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	if Verbose() {
-		fmt.Println("Performing 'go build'...")
-	}
+	logf(project, "Performing 'go build'...\n")
 
-	err := util.ExecCmd(exec.Command("go", "build", "-o", project.Executable()), project.SrcDir())
+	exe := project.Executable()
+	err := execCmd(project, goBuildCmd(project, exe, reproducible), project.SrcDir())
 	if err != nil {
-		fmt.Println("Error in building", project.SrcDir())
-		return err
+		eprintf(project, "Error in building %s\n", project.SrcDir())
+		return "", err
+	}
+
+	return exe, nil
+}
+
+// goBuildCmd returns the go build command of the executable with the environment of the session of the project,
+// reproducible builds strip the paths and build id of the build machine and disable cgo unless it is explicitly enabled
+func goBuildCmd(project common.AppProject, exe string, reproducible bool) *exec.Cmd {
+
+	session := common.ProjectSession(project)
+
+	if !reproducible {
+		cmd := exec.Command("go", "build", "-o", exe)
+		cmd.Env = session.Environ()
+		return cmd
+	}
+
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags=-buildid=", "-o", exe)
+	cmd.Env = session.Environ()
+	if _, ok := session.LookupEnv("CGO_ENABLED"); !ok {
+		cmd.Env = append(cmd.Env, "CGO_ENABLED=0")
 	}
 
-	return nil
+	return cmd
 }
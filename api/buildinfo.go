@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	fileBuildInfoGo string = "buildinfo.go"
+)
+
+// collectBuildInfo returns the build information of the project, the app name and version are those of the
+// descriptor with the overlays of the environment applied
+func collectBuildInfo(project common.AppProject, env string, buildTime time.Time) (*common.BuildInfo, error) {
+
+	appJson, err := RenderAppDescriptor(project, env)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := util.ParseAppDescriptor(appJson)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &common.BuildInfo{
+		AppName:    descriptor.Name,
+		AppVersion: descriptor.Version,
+		BuildTime:  buildTime.UTC().Truncate(time.Second),
+		CLIVersion: common.CLIVersion(),
+	}
+
+	info.Revision, info.Modified = vcsRevision(project)
+
+	info.Contributions, err = resolvedContributions(project, descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// vcsRevision returns the git commit of the project and whether its tracked files have uncommitted changes, the
+// revision is empty if the project isn't in a git repository
+func vcsRevision(project common.AppProject) (string, bool) {
+
+	environ := common.ProjectSession(project).Environ()
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = project.Dir()
+	cmd.Env = environ
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	revision := strings.TrimSpace(string(out))
+
+	cmd = exec.Command("git", "status", "--porcelain", "--untracked-files=no")
+	cmd.Dir = project.Dir()
+	cmd.Env = environ
+	out, err = cmd.Output()
+
+	return revision, err == nil && len(strings.TrimSpace(string(out))) > 0
+}
+
+// resolvedContributions returns the contributions of the app and engine with the versions resolved by go.mod
+func resolvedContributions(project common.AppProject, descriptor *util.AIflowAppDescriptor) ([]string, error) {
+
+	imports, err := contribImports(project, descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	goModImports, err := project.DepManager().GetAllImports()
+	if err != nil {
+		// the versions are unknown without go.mod
+		goModImports = nil
+	}
+
+	found := make(map[string]bool)
+	var contribs []string
+	for _, imp := range imports {
+		contrib := imp.GoImportPath()
+		if version := resolvedModuleVersion(goModImports, contrib); version != "" {
+			contrib += "@" + version
+		}
+		if !found[contrib] {
+			found[contrib] = true
+			contribs = append(contribs, contrib)
+		}
+	}
+	sort.Strings(contribs)
+
+	return contribs, nil
+}
+
+// contribImports returns the imports of the app descriptor and of engine.json
+func contribImports(project common.AppProject, descriptor *util.AIflowAppDescriptor) ([]util.Import, error) {
+
+	imports, err := util.ParseImports(descriptor.Imports)
+	if err != nil {
+		return nil, err
+	}
+
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	if util.FileExists(engineJsonPath) {
+		engineImports, err := util.GetEngineImports(engineJsonPath, project.DepManager())
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, engineImports.GetAllImports()...)
+	}
+
+	return imports, nil
+}
+
+// resolvedModuleVersion returns the go.mod version of the module providing the package
+func resolvedModuleVersion(goModImports map[string]util.Import, pkg string) string {
+
+	module := providingModule(goModImports, pkg)
+	if module == "" {
+		return ""
+	}
+
+	return goModImports[module].Version()
+}
+
+// providingModule returns the go.mod module whose path is the longest prefix of the package
+func providingModule(goModImports map[string]util.Import, pkg string) string {
+
+	module := ""
+	for path := range goModImports {
+		if (pkg == path || strings.HasPrefix(pkg, path+"/")) && len(path) > len(module) {
+			module = path
+		}
+	}
+
+	return module
+}
+
+// createBuildInfoGoFile generates the file stamping the build information into the app
+func createBuildInfoGoFile(project common.AppProject, info *common.BuildInfo) error {
+
+	infoJson, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s\n", info.AppName, info.AppVersion)
+	if info.Revision != "" {
+		revision := info.Revision
+		if info.Modified {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(&text, "revision: %s\n", revision)
+	}
+	fmt.Fprintf(&text, "built: %s\n", info.BuildTime.Format(time.RFC3339))
+	if info.CLIVersion != "" {
+		fmt.Fprintf(&text, "cli version: %s\n", info.CLIVersion)
+	}
+	if len(info.Contributions) > 0 {
+		fmt.Fprintf(&text, "contributions:\n")
+		for _, contrib := range info.Contributions {
+			fmt.Fprintf(&text, "  %s\n", contrib)
+		}
+	}
+
+	data := struct {
+		Text string
+		JSON string
+	}{
+		strings.TrimSuffix(text.String(), "\n"),
+		string(infoJson),
+	}
+
+	f, err := os.Create(filepath.Join(project.SrcDir(), fileBuildInfoGo))
+	if err != nil {
+		return err
+	}
+	RenderTemplate(f, tplBuildInfoGoFile, &data)
+	_ = f.Close()
+
+	return nil
+}
+
+func cleanupBuildInfoGoFile(project common.AppProject) {
+	buildInfoPath := filepath.Join(project.SrcDir(), fileBuildInfoGo)
+	if util.FileExists(buildInfoPath) {
+		if err := os.Remove(buildInfoPath); err != nil {
+			eprintf(project, "Error removing build info file '%s': %v\n", buildInfoPath, err)
+		}
+	}
+}
+
+var tplBuildInfoGoFile = `// Do not change this file, it has been generated using AIflow-cli
+// If you change it and rebuild the application your changes might get lost
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// build information of the app, printed with --version or as JSON with --version=json
+const aiflowBuildInfo = {{printf "%q" .Text}}
+const aiflowBuildInfoJSON = {{printf "%q" .JSON}}
+
+func init() {
+	if len(os.Args) != 2 {
+		return
+	}
+
+	switch os.Args[1] {
+	case "--version", "-version":
+		fmt.Println(aiflowBuildInfo)
+		os.Exit(0)
+	case "--version=json", "-version=json":
+		fmt.Println(aiflowBuildInfoJSON)
+		os.Exit(0)
+	}
+}
+`
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvedModuleVersion(t *testing.T) {
+
+	goModImports := make(map[string]util.Import)
+	for _, imp := range []string{"github.com/r2d2-ai/aiflow@v1.0.0", "github.com/r2d2-ai/aiflow/contrib@v0.9.0"} {
+		modImport, err := util.ParseImport(imp)
+		assert.Nil(t, err)
+		goModImports[modImport.GoImportPath()] = modImport
+	}
+
+	assert.Equal(t, "v0.9.0", resolvedModuleVersion(goModImports, "github.com/r2d2-ai/aiflow/contrib/activity/log"))
+	assert.Equal(t, "v1.0.0", resolvedModuleVersion(goModImports, "github.com/r2d2-ai/aiflow/action/flow"))
+	assert.Equal(t, "", resolvedModuleVersion(goModImports, "github.com/r2d2-ai/aiflowx"))
+	assert.Equal(t, "", resolvedModuleVersion(nil, "github.com/r2d2-ai/aiflow"))
+}
+
+func TestBuildInfoGoFile(t *testing.T) {
+
+	data := struct {
+		Text string
+		JSON string
+	}{
+		"myapp 1.0.0\nrevision: \"abc\" (modified)",
+		`{"appName":"myapp","appVersion":"1.0.0"}`,
+	}
+
+	var buf bytes.Buffer
+	RenderTemplate(&buf, tplBuildInfoGoFile, &data)
+
+	file, err := parser.ParseFile(token.NewFileSet(), fileBuildInfoGo, buf.Bytes(), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "main", file.Name.Name)
+
+	consts := make(map[string]string)
+	hasInit := false
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if value, ok := spec.(*ast.ValueSpec); ok && decl.Tok == token.CONST {
+					lit := value.Values[0].(*ast.BasicLit)
+					consts[value.Names[0].Name], err = strconv.Unquote(lit.Value)
+					assert.Nil(t, err)
+				}
+			}
+		case *ast.FuncDecl:
+			hasInit = hasInit || decl.Name.Name == "init"
+		}
+	}
+
+	// the names must not clash with the declarations of the package main of the app
+	assert.Equal(t, map[string]string{"aiflowBuildInfo": data.Text, "aiflowBuildInfoJSON": data.JSON}, consts)
+	assert.True(t, hasInit)
+}
+
+func TestNeedsBuildInfo(t *testing.T) {
+
+	registered := common.Hooks()
+	defer common.SetHooks(registered)
+	common.SetHooks(nil)
+
+	// the post processors registered by the other tests don't receive the result
+	assert.False(t, needsBuildInfo(common.BuildOptions{}))
+	assert.True(t, needsBuildInfo(common.BuildOptions{StampInfo: true}))
+
+	common.RegisterHook(common.HookFunc(func(event common.Event) error { return nil }))
+	assert.True(t, needsBuildInfo(common.BuildOptions{}))
+}
@@ -14,7 +14,28 @@ import (
 
 var fileSampleEngineMain = filepath.Join("core", "examples", "engine", "main.go")
 
+// CreateOptions are the options of the creation of an app project
+type CreateOptions struct {
+	Session         *common.Session // session the project is bound to, the default session if nil
+	MigrateAppModel bool            // migrate an app descriptor of an older app model to the latest one supported by the core library
+}
+
 func CreateProject(basePath, appName, appCfgPath, coreVersion string) (common.AppProject, error) {
+	return CreateProjectWithOptions(basePath, appName, appCfgPath, coreVersion, CreateOptions{})
+}
+
+// CreateProjectInSession creates an app project bound to the session
+func CreateProjectInSession(session *common.Session, basePath, appName, appCfgPath, coreVersion string) (common.AppProject, error) {
+	return CreateProjectWithOptions(basePath, appName, appCfgPath, coreVersion, CreateOptions{Session: session})
+}
+
+// CreateProjectWithOptions creates an app project with the options
+func CreateProjectWithOptions(basePath, appName, appCfgPath, coreVersion string, options CreateOptions) (common.AppProject, error) {
+
+	session := options.Session
+	if session == nil {
+		session = common.DefaultSession()
+	}
 
 	var err error
 	var appJson string
@@ -44,7 +65,12 @@ func CreateProject(basePath, appName, appCfgPath, coreVersion string) (common.Ap
 		return nil, err
 	}
 
-	fmt.Printf("Creating AIflow App: %s\n", appName)
+	err = fireEvent(&common.CreateProjectEvent{Phase: common.PhasePre, BasePath: basePath, AppName: appName, AppJson: appJson})
+	if err != nil {
+		return nil, err
+	}
+
+	session.Printf("Creating AIflow App: %s\n", appName)
 
 	appDir, err := createAppDirectory(basePath, appName)
 	if err != nil {
@@ -52,22 +78,25 @@ func CreateProject(basePath, appName, appCfgPath, coreVersion string) (common.Ap
 	}
 
 	srcDir := filepath.Join(appDir, "src")
-	dm := util.NewDepManager(srcDir)
+	dm := util.NewDepManagerInEnv(srcDir, session)
 
-	if Verbose() {
-		fmt.Printf("Setting up app directory: %s\n", appDir)
-	}
+	session.Logf("Setting up app directory: %s\n", appDir)
 
-	err = setupAppDirectory(dm, appDir, coreVersion)
+	err = setupAppDirectory(session, dm, appDir, coreVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	if Verbose() {
-		if appJson == "" {
-			fmt.Println("Adding sample AIflow.json")
+	if options.MigrateAppModel && appJson != "" {
+		appJson, err = migrateAppJsonModel(session, dm, appJson)
+		if err != nil {
+			return nil, err
 		}
 	}
+
+	if appJson == "" {
+		session.Logf("Adding sample AIflow.json\n")
+	}
 	err = createAppJson(dm, appDir, appName, appJson)
 	if err != nil {
 		return nil, err
@@ -78,21 +107,22 @@ func CreateProject(basePath, appName, appCfgPath, coreVersion string) (common.Ap
 		return nil, err
 	}
 
-	project := NewAppProject(appDir)
+	project := NewAppProjectInSession(session, appDir)
 
-	if Verbose() {
-		fmt.Println("Importing Dependencies...")
-	}
+	logf(project, "Importing Dependencies...\n")
 
 	err = importDependencies(project)
 	if err != nil {
 		return nil, err
 	}
 
-	if Verbose() {
-		fmt.Printf("Created App: %s\n", appName)
+	err = fireEvent(&common.CreateProjectEvent{Phase: common.PhasePost, BasePath: basePath, AppName: appName, AppJson: appJson, Project: project})
+	if err != nil {
+		return nil, err
 	}
 
+	logf(project, "Created App: %s\n", appName)
+
 	return project, nil
 }
 
@@ -118,7 +148,7 @@ func createAppDirectory(basePath, appName string) (string, error) {
 }
 
 //setupAppDirectory sets up the AIflow app directory
-func setupAppDirectory(dm util.DepManager, appPath, coreVersion string) error {
+func setupAppDirectory(session *common.Session, dm util.DepManager, appPath, coreVersion string) error {
 
 	err := os.Mkdir(filepath.Join(appPath, dirBin), os.ModePerm)
 	if err != nil {
@@ -149,9 +179,9 @@ func setupAppDirectory(dm util.DepManager, appPath, coreVersion string) error {
 
 	//todo get the actual version installed from the go.mod file
 	if coreVersion == "" {
-		fmt.Printf("Installing: %s@latest\n", flowCoreImport.CanonicalImport())
+		session.Printf("Installing: %s@latest\n", flowCoreImport.CanonicalImport())
 	} else {
-		fmt.Printf("Installing: %s\n", flowCoreImport.CanonicalImport())
+		session.Printf("Installing: %s\n", flowCoreImport.CanonicalImport())
 	}
 
 	// add & fetch the core library
@@ -219,13 +249,13 @@ func importDependencies(project common.AppProject) error {
 			if desc.IsLegacy {
 				legacySupportRequired = true
 				cType = "legacy " + desc.GetContribType()
-				err := CreateLegacyMetadata(path, desc.GetContribType(), details.Imp.GoImportPath())
+				err := createLegacyMetadata(common.ProjectSession(project), path, desc.GetContribType(), details.Imp.GoImportPath())
 				if err != nil {
 					return err
 				}
 			}
 
-			fmt.Printf("Installed %s: %s\n", cType, details.Imp)
+			printf(project, "Installed %s: %s\n", cType, details.Imp)
 			//instStr := fmt.Sprintf("Installed %s:", cType)
 			//fmt.Printf("%-20s %s\n", instStr, imp)
 		}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// WriteAppDocs generates the Markdown documentation of the project's app
+func WriteAppDocs(w io.Writer, project common.AppProject) error {
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return err
+	}
+
+	resolver := &refResolver{project: project, appImports: appImports}
+
+	name, _ := appObj["name"].(string)
+	fmt.Fprintf(w, "# %s\n\n", name)
+	if description, _ := appObj["description"].(string); description != "" {
+		fmt.Fprintf(w, "%s\n\n", description)
+	}
+	fmt.Fprintf(w, "- Version: %v\n", valueOrDash(appObj["version"]))
+	fmt.Fprintf(w, "- App model: %v\n\n", valueOrDash(appObj["appModel"]))
+
+	writeTriggerDocs(w, resolver, appObj)
+	writeFlowDocs(w, resolver, appObj)
+	writeContribsDocs(w, appImports)
+
+	return nil
+}
+
+func writeTriggerDocs(w io.Writer, resolver *refResolver, appObj map[string]interface{}) {
+
+	triggers := toMaps(appObj["triggers"])
+	if len(triggers) == 0 {
+		return
+	}
+
+	fmt.Fprint(w, "## Triggers\n\n")
+
+	for _, trg := range triggers {
+		id, _ := trg["id"].(string)
+		ref, _ := trg["ref"].(string)
+
+		fmt.Fprintf(w, "### %s\n\n", id)
+		if trgName, _ := trg["name"].(string); trgName != "" {
+			fmt.Fprintf(w, "%s\n\n", trgName)
+		}
+		fmt.Fprintf(w, "- Trigger: %s\n", resolver.contribName(ref, "trigger"))
+		fmt.Fprintf(w, "- Ref: `%s`\n\n", ref)
+
+		if settings, ok := trg["settings"].(map[string]interface{}); ok && len(settings) > 0 {
+			fmt.Fprint(w, "| Setting | Value |\n|---|---|\n")
+			for _, key := range sortedMapKeys(settings) {
+				fmt.Fprintf(w, "| %s | %s |\n", mdCell(key), mdCell(formatValue(settings[key])))
+			}
+			fmt.Fprintln(w)
+		}
+
+		handlers := toMaps(trg["handlers"])
+		if len(handlers) == 0 {
+			continue
+		}
+
+		fmt.Fprint(w, "| Handler | Endpoint | Action |\n|---|---|---|\n")
+		for i, handler := range handlers {
+			handlerName, _ := handler["name"].(string)
+			if handlerName == "" {
+				handlerName = fmt.Sprintf("handler %d", i+1)
+			}
+
+			var endpoint []string
+			if settings, ok := handler["settings"].(map[string]interface{}); ok {
+				for _, key := range sortedMapKeys(settings) {
+					endpoint = append(endpoint, key+": "+formatValue(settings[key]))
+				}
+			}
+
+			var actions []string
+			if action, ok := handler["action"].(map[string]interface{}); ok {
+				actions = append(actions, actionSummary(resolver, action))
+			}
+			for _, action := range toMaps(handler["actions"]) {
+				actions = append(actions, actionSummary(resolver, action))
+			}
+
+			fmt.Fprintf(w, "| %s | %s | %s |\n", mdCell(handlerName), mdCell(strings.Join(endpoint, ", ")), mdCell(strings.Join(actions, ", ")))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func actionSummary(resolver *refResolver, action map[string]interface{}) string {
+	ref, _ := action["ref"].(string)
+	summary := resolver.contribName(ref, "action")
+	if summary == "" {
+		summary, _ = action["id"].(string)
+	}
+
+	if settings, ok := action["settings"].(map[string]interface{}); ok {
+		if flowURI, ok := settings["flowURI"].(string); ok {
+			summary += " " + flowURI
+		}
+	}
+
+	return strings.TrimSpace(summary)
+}
+
+func writeFlowDocs(w io.Writer, resolver *refResolver, appObj map[string]interface{}) {
+
+	resources := mapsById(appObj["resources"])
+
+	var flowIds []string
+	for _, id := range sortedKeys(resources) {
+		if strings.HasPrefix(id, "flow:") {
+			flowIds = append(flowIds, id)
+		}
+	}
+
+	if len(flowIds) == 0 {
+		return
+	}
+
+	fmt.Fprint(w, "## Flows\n\n")
+
+	for _, id := range flowIds {
+		data, _ := resources[id]["data"].(map[string]interface{})
+		name, _ := data["name"].(string)
+		if name == "" {
+			name = id
+		}
+
+		fmt.Fprintf(w, "### %s\n\n", name)
+		fmt.Fprintf(w, "- URI: `%s%s`\n\n", resURIPrefix, id)
+		if description, _ := data["description"].(string); description != "" {
+			fmt.Fprintf(w, "%s\n\n", description)
+		}
+
+		metadata, _ := data["metadata"].(map[string]interface{})
+		writeFlowAttrs(w, "Inputs", toMaps(metadata["input"]))
+		writeFlowAttrs(w, "Outputs", toMaps(metadata["output"]))
+
+		tasks := toMaps(data["tasks"])
+		if len(tasks) > 0 {
+			fmt.Fprint(w, "#### Activities\n\n| Task | Activity |\n|---|---|\n")
+			for _, task := range tasks {
+				taskName, _ := task["name"].(string)
+				if taskName == "" {
+					taskName, _ = task["id"].(string)
+				}
+				activity, _ := task["activity"].(map[string]interface{})
+				ref, _ := activity["ref"].(string)
+				fmt.Fprintf(w, "| %s | %s |\n", mdCell(taskName), mdCell(resolver.contribName(ref, "activity")))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func writeFlowAttrs(w io.Writer, title string, attrs []map[string]interface{}) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "#### %s\n\n| Name | Type | Value |\n|---|---|---|\n", title)
+	for _, attr := range attrs {
+		fmt.Fprintf(w, "| %s | %s | %s |\n", mdCell(formatValue(attr["name"])), mdCell(formatValue(attr["type"])), mdCell(formatValue(attr["value"])))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeContribsDocs(w io.Writer, appImports *util.AppImports) {
+
+	var contribs []*util.AppImportDetails
+	for _, details := range appImports.GetAllImportDetails() {
+		if details.ContribDesc != nil {
+			contribs = append(contribs, details)
+		}
+	}
+
+	if len(contribs) == 0 {
+		return
+	}
+
+	sort.Slice(contribs, func(i, j int) bool {
+		return contribs[i].Imp.GoImportPath() < contribs[j].Imp.GoImportPath()
+	})
+
+	fmt.Fprint(w, "## Contributions\n\n| Name | Type | Ref | Version | Homepage |\n|---|---|---|---|---|\n")
+	for _, details := range contribs {
+		desc := details.ContribDesc
+		version := desc.Version
+		if version == "" {
+			version = details.Imp.Version()
+		}
+		fmt.Fprintf(w, "| %s | %s | `%s` | %s | %s |\n", mdCell(desc.Name), desc.GetContribType(), details.Imp.GoImportPath(), mdCell(version), mdCell(desc.Homepage))
+	}
+	fmt.Fprintln(w)
+}
+
+// WriteContribDocs generates a Markdown README from the descriptor of the contribution in the specified directory
+func WriteContribDocs(w io.Writer, contribPath string) error {
+
+	desc, err := util.GetContribDescriptor(contribPath)
+	if err != nil {
+		return err
+	}
+	if desc == nil {
+		return fmt.Errorf("contribution descriptor not found in '%s'", contribPath)
+	}
+
+	title := desc.Title
+	if title == "" {
+		title = desc.Name
+	}
+
+	fmt.Fprintf(w, "# %s\n\n", title)
+	if desc.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", desc.Description)
+	}
+
+	fmt.Fprintf(w, "- Name: %s\n", desc.Name)
+	fmt.Fprintf(w, "- Type: %s\n", desc.GetContribType())
+	fmt.Fprintf(w, "- Version: %s\n", valueOrDash(desc.Version))
+	if desc.Author != "" {
+		fmt.Fprintf(w, "- Author: %s\n", desc.Author)
+	}
+	if desc.Homepage != "" {
+		fmt.Fprintf(w, "- Homepage: %s\n", desc.Homepage)
+	}
+	fmt.Fprintln(w)
+
+	ref := desc.Ref
+	if ref == "" {
+		ref = getModuleImportPath(contribPath)
+	}
+	if ref != "" {
+		fmt.Fprintf(w, "## Installation\n\n```bash\nAIflow install %s\n```\n\n", ref)
+	}
+
+	writeContribAttrs(w, "Settings", desc.Settings)
+	if desc.Handler != nil {
+		writeContribAttrs(w, "Handler Settings", desc.Handler.Settings)
+	}
+	writeContribAttrs(w, "Input", desc.GetInput())
+	writeContribAttrs(w, "Output", desc.GetOutput())
+	writeContribAttrs(w, "Reply", desc.Reply)
+
+	if len(desc.Examples) > 0 {
+		fmt.Fprint(w, "## Examples\n\n")
+		for _, example := range desc.Examples {
+			buf, err := json.MarshalIndent(example, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "```json\n%s\n```\n\n", string(buf))
+		}
+	}
+
+	return nil
+}
+
+func writeContribAttrs(w io.Writer, title string, attrs []*util.ContribAttribute) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "## %s\n\n| Name | Type | Required | Default | Description |\n|---|---|---|---|---|\n", title)
+	for _, attr := range attrs {
+		def := formatValue(attr.Value)
+		if len(attr.Allowed) > 0 {
+			var allowed []string
+			for _, val := range attr.Allowed {
+				allowed = append(allowed, formatValue(val))
+			}
+			def = strings.TrimSpace(def + " (allowed: " + strings.Join(allowed, ", ") + ")")
+		}
+		required := ""
+		if attr.Required {
+			required = "yes"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", mdCell(attr.Name), mdCell(attr.Type), required, mdCell(def), mdCell(attr.Description))
+	}
+	fmt.Fprintln(w)
+}
+
+// getModuleImportPath determines the Go import path of a directory from the enclosing go.mod
+func getModuleImportPath(dir string) string {
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for modDir := absDir; ; modDir = filepath.Dir(modDir) {
+		if f, err := os.Open(filepath.Join(modDir, "go.mod")); err == nil {
+			defer f.Close()
+
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) == 2 && fields[0] == "module" {
+					rel, err := filepath.Rel(modDir, absDir)
+					if err != nil || rel == "." {
+						return fields[1]
+					}
+					return fields[1] + "/" + filepath.ToSlash(rel)
+				}
+			}
+			return ""
+		}
+
+		if filepath.Dir(modDir) == modDir {
+			return ""
+		}
+	}
+}
+
+func formatValue(val interface{}) string {
+	switch t := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		buf, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprintf("%v", t)
+		}
+		return string(buf)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+func valueOrDash(val interface{}) string {
+	str := formatValue(val)
+	if str == "" {
+		return "-"
+	}
+	return str
+}
+
+func mdCell(str string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(str)
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
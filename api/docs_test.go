@@ -0,0 +1,51 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var activityDescriptor = `{
+  "name": "log",
+  "type": "AIflow:activity",
+  "version": "0.0.1",
+  "title": "Log Message",
+  "description": "Logs a message",
+  "settings": [
+    { "name": "level", "type": "string", "allowed": ["INFO", "DEBUG"], "value": "INFO" }
+  ],
+  "input": [
+    { "name": "message", "type": "string", "required": true, "description": "the message | to log" }
+  ],
+  "output": []
+}`
+
+func TestWriteContribDocs(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "contrib")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	err = ioutil.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module github.com/myuser/log\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(tempDir, "descriptor.json"), []byte(activityDescriptor), 0644)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	err = WriteContribDocs(&buf, tempDir)
+	assert.Nil(t, err)
+
+	readme := buf.String()
+	assert.Contains(t, readme, "# Log Message")
+	assert.Contains(t, readme, "AIflow install github.com/myuser/log")
+	assert.Contains(t, readme, "| level | string |  | INFO (allowed: INFO, DEBUG) |  |")
+	assert.Contains(t, readme, "| message | string | yes |  | the message \\| to log |")
+	assert.NotContains(t, readme, "## Output")
+
+	err = WriteContribDocs(&buf, filepath.Join(tempDir, "missing"))
+	assert.NotNil(t, err)
+}
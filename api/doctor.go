@@ -0,0 +1,398 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// minimum Go version, as required by the CLI and the app go.mod
+const minGoMinorVersion = 16
+
+type DoctorStatus int
+
+const (
+	DoctorPass DoctorStatus = iota
+	DoctorWarn
+	DoctorFail
+)
+
+func (s DoctorStatus) String() string {
+	switch s {
+	case DoctorWarn:
+		return "warn"
+	case DoctorFail:
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+// DoctorCheck is the result of a diagnostic check, Hint explains how to fix a warning or failure
+type DoctorCheck struct {
+	Name    string
+	Status  DoctorStatus
+	Message string
+	Hint    string
+}
+
+// RunDoctor checks the environment needed by the CLI and, if specified, the integrity of the project
+func RunDoctor(project common.AppProject) []*DoctorCheck {
+
+	var checks []*DoctorCheck
+
+	goCheck := checkGoToolchain()
+	checks = append(checks, goCheck)
+
+	if goCheck.Status != DoctorFail {
+		checks = append(checks, checkGoPath(), checkModCache(), checkGoProxy())
+	}
+
+	checks = append(checks, checkTool("git", "git", "required to fetch contributions from version control and to determine versions",
+		"install git and make sure it is on the PATH"))
+	checks = append(checks, checkTool("make", "make", "required to build shims that provide a Makefile",
+		"install make to build shims that provide a Makefile"))
+
+	if goCheck.Status != DoctorFail {
+		checks = append(checks, checkCLISource())
+	}
+
+	if project != nil {
+		checks = append(checks, checkProject(project)...)
+	}
+
+	return checks
+}
+
+// PrintDoctorReport prints the checks and returns the number of failed checks
+func PrintDoctorReport(w io.Writer, checks []*DoctorCheck) int {
+
+	failed := 0
+	warned := 0
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%s] %s: %s\n", check.Status, check.Name, check.Message)
+		if check.Status != DoctorPass && check.Hint != "" {
+			fmt.Fprintf(w, "       hint: %s\n", check.Hint)
+		}
+
+		switch check.Status {
+		case DoctorFail:
+			failed++
+		case DoctorWarn:
+			warned++
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d checks, %d warnings, %d failures\n", len(checks), warned, failed)
+
+	return failed
+}
+
+func checkGoToolchain() *DoctorCheck {
+
+	check := &DoctorCheck{Name: "go toolchain"}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		check.Status = DoctorFail
+		check.Message = "go not found on the PATH"
+		check.Hint = "install Go from https://golang.org/dl and add its bin directory to the PATH"
+		return check
+	}
+
+	goVersion, err := goEnv("GOVERSION")
+	if err != nil || goVersion == "" {
+		// GOVERSION is only available since go 1.16
+		goVersion = goVersionOutput()
+	}
+	if goVersion == "" {
+		check.Status = DoctorFail
+		check.Message = "unable to determine the Go version"
+		check.Hint = fmt.Sprintf("install Go 1.%d or later", minGoMinorVersion)
+		return check
+	}
+
+	check.Message = goVersion
+
+	minor, ok := goMinorVersion(goVersion)
+	if !ok {
+		check.Status = DoctorWarn
+		check.Hint = fmt.Sprintf("unrecognized Go version, Go 1.%d or later is required", minGoMinorVersion)
+	} else if minor < minGoMinorVersion {
+		check.Status = DoctorFail
+		check.Hint = fmt.Sprintf("upgrade to Go 1.%d or later", minGoMinorVersion)
+	}
+
+	return check
+}
+
+// goVersionOutput returns the version printed by 'go version', "go version go1.15.2 linux/amd64", for the
+// toolchains that don't report GOVERSION
+func goVersionOutput() string {
+	out, err := exec.Command("go", "version").Output()
+	if err != nil {
+		return ""
+	}
+	return parseGoVersionOutput(string(out))
+}
+
+func parseGoVersionOutput(out string) string {
+	fields := strings.Fields(out)
+	if len(fields) < 3 || fields[0] != "go" || fields[1] != "version" {
+		return ""
+	}
+	return fields[2]
+}
+
+func goMinorVersion(goVersion string) (int, bool) {
+	parts := strings.Split(strings.TrimPrefix(goVersion, "go"), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, false
+	}
+
+	minor := parts[1]
+	if idx := strings.IndexFunc(minor, func(r rune) bool { return r < '0' || r > '9' }); idx >= 0 {
+		minor = minor[:idx]
+	}
+
+	val, err := strconv.Atoi(minor)
+	return val, err == nil
+}
+
+func checkGoPath() *DoctorCheck {
+
+	check := &DoctorCheck{Name: "GOPATH"}
+
+	goPath, err := goEnv("GOPATH")
+	if err != nil || goPath == "" {
+		check.Status = DoctorFail
+		check.Message = "GOPATH is not set"
+		check.Hint = "set the GOPATH environment variable, for example to $HOME/go"
+		return check
+	}
+
+	check.Message = goPath
+	return check
+}
+
+func checkModCache() *DoctorCheck {
+
+	check := &DoctorCheck{Name: "module cache"}
+
+	modCache, err := goEnv("GOMODCACHE")
+	if err != nil || modCache == "" {
+		check.Status = DoctorFail
+		check.Message = "unable to determine the module cache location"
+		check.Hint = "check the output of 'go env GOMODCACHE'"
+		return check
+	}
+
+	check.Message = modCache
+
+	// the module cache is created on first use, so check the nearest existing directory
+	dir := modCache
+	for !util.FileExists(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+
+	f, err := ioutil.TempFile(dir, ".aiflow-doctor")
+	if err != nil {
+		check.Status = DoctorFail
+		check.Message = modCache + " is not writable"
+		check.Hint = "fix the permissions of the module cache or set GOMODCACHE to a writable directory"
+		return check
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	return check
+}
+
+func checkGoProxy() *DoctorCheck {
+
+	check := &DoctorCheck{Name: "go proxy"}
+
+	proxy, _ := goEnv("GOPROXY")
+	private, _ := goEnv("GOPRIVATE")
+
+	check.Message = "GOPROXY=" + proxy
+	if private != "" {
+		check.Message += ", GOPRIVATE=" + private
+	}
+
+	if proxy == "off" {
+		check.Status = DoctorWarn
+		check.Hint = "GOPROXY=off prevents downloading contributions, only the module cache can be used"
+	} else if proxy == "" {
+		check.Status = DoctorWarn
+		check.Hint = "GOPROXY is empty, set it to https://proxy.golang.org,direct or to your organization's proxy"
+	}
+
+	return check
+}
+
+func checkTool(name, tool, purpose, hint string) *DoctorCheck {
+
+	check := &DoctorCheck{Name: name}
+
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		check.Status = DoctorWarn
+		check.Message = tool + " not found on the PATH, " + purpose
+		check.Hint = hint
+		return check
+	}
+
+	check.Message = path
+	return check
+}
+
+func checkCLISource() *DoctorCheck {
+
+	check := &DoctorCheck{Name: "CLI source"}
+
+	path, err := util.FindCLISource()
+	if err != nil || path == "" {
+		check.Status = DoctorWarn
+		check.Message = "CLI source not found in the module cache, it is required to install plugins"
+		check.Hint = "run 'go get " + util.CLIPackage + "' to download the CLI source"
+		return check
+	}
+
+	check.Message = path
+	return check
+}
+
+func checkProject(project common.AppProject) []*DoctorCheck {
+
+	var checks []*DoctorCheck
+
+	check := &DoctorCheck{Name: "project", Message: project.Dir()}
+	checks = append(checks, check)
+
+	if err := project.Validate(); err != nil {
+		check.Status = DoctorFail
+		check.Message = err.Error()
+		check.Hint = "recreate the project with 'AIflow create -f AIflow.json'"
+		return checks
+	}
+
+	descCheck := &DoctorCheck{Name: "app descriptor", Message: fileAIflowJson + " is valid"}
+	checks = append(checks, descCheck)
+
+	if _, err := readAppDescriptor(project); err != nil {
+		descCheck.Status = DoctorFail
+		descCheck.Message = fileAIflowJson + " is invalid: " + err.Error()
+		descCheck.Hint = "fix the JSON syntax of " + fileAIflowJson
+		return checks
+	}
+
+	mainCheck := &DoctorCheck{Name: "main", Message: "src/" + fileMainGo + " found"}
+	checks = append(checks, mainCheck)
+
+	mainGo := filepath.Join(project.SrcDir(), fileMainGo)
+	if !util.FileExists(mainGo) {
+		if util.FileExists(mainGo + ".bak") {
+			mainCheck.Status = DoctorWarn
+			mainCheck.Message = "src/" + fileMainGo + " was left renamed by an interrupted shim build"
+			mainCheck.Hint = "rename src/" + fileMainGo + ".bak to src/" + fileMainGo
+		} else {
+			mainCheck.Status = DoctorFail
+			mainCheck.Message = "src/" + fileMainGo + " is missing"
+			mainCheck.Hint = "recreate the project with 'AIflow create -f AIflow.json'"
+		}
+	}
+
+	checks = append(checks, checkLeftoverFiles(project))
+	checks = append(checks, checkProjectImports(project)...)
+
+	return checks
+}
+
+func checkLeftoverFiles(project common.AppProject) *DoctorCheck {
+
+	check := &DoctorCheck{Name: "generated files", Message: "no leftover generated files"}
+
+	var leftovers []string
+	for _, file := range []string{fileImportsGo + ".orig", fileRecordAppGo, fileFlowTestGo, fileShimGo, fileShimSupportGo} {
+		if util.FileExists(filepath.Join(project.SrcDir(), file)) {
+			leftovers = append(leftovers, "src/"+file)
+		}
+	}
+
+	if len(leftovers) > 0 {
+		check.Status = DoctorWarn
+		check.Message = "files left by an interrupted command: " + strings.Join(leftovers, ", ")
+		check.Hint = "remove the files, or restore src/" + fileImportsGo + " from src/" + fileImportsGo + ".orig if present"
+	}
+
+	return check
+}
+
+func checkProjectImports(project common.AppProject) []*DoctorCheck {
+
+	syncCheck := &DoctorCheck{Name: "imports", Message: "Go imports match the app imports"}
+	checks := []*DoctorCheck{syncCheck}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), false)
+	if err != nil {
+		syncCheck.Status = DoctorFail
+		syncCheck.Message = "unable to read the app imports: " + err.Error()
+		return checks
+	}
+
+	goImports, err := project.GetGoImports(false)
+	if err != nil {
+		syncCheck.Status = DoctorFail
+		syncCheck.Message = "unable to read src/" + fileImportsGo + ": " + err.Error()
+		return checks
+	}
+
+	goImportsMap := make(map[string]bool)
+	for _, imp := range goImports {
+		goImportsMap[imp.GoImportPath()] = true
+	}
+
+	var missing []string
+	for _, imp := range appImports.GetAllImports() {
+		if !goImportsMap[imp.GoImportPath()] {
+			missing = append(missing, imp.GoImportPath())
+		}
+	}
+
+	if len(missing) > 0 {
+		syncCheck.Status = DoctorWarn
+		syncCheck.Message = "missing Go imports: " + strings.Join(missing, ", ")
+		syncCheck.Hint = "run 'AIflow imports sync'"
+	}
+
+	conflictCheck := &DoctorCheck{Name: "import conflicts", Message: "no conflicting imports"}
+	checks = append(checks, conflictCheck)
+
+	if conflicts := appImports.GetImportConflicts(); len(conflicts) > 0 {
+		conflictCheck.Status = DoctorWarn
+		var messages []string
+		for _, conflict := range conflicts {
+			messages = append(messages, conflict.String())
+		}
+		conflictCheck.Message = strings.Join(messages, "; ")
+		conflictCheck.Hint = "use 'AIflow imports alias' to rename the conflicting aliases"
+	}
+
+	return checks
+}
+
+func goEnv(name string) (string, error) {
+	out, err := exec.Command("go", "env", name).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoMinorVersion(t *testing.T) {
+
+	minor, ok := goMinorVersion("go1.16.5")
+	assert.True(t, ok)
+	assert.Equal(t, 16, minor)
+
+	minor, ok = goMinorVersion("go1.21rc2")
+	assert.True(t, ok)
+	assert.Equal(t, 21, minor)
+
+	_, ok = goMinorVersion("devel +abc")
+	assert.False(t, ok)
+
+	minor, ok = goMinorVersion(parseGoVersionOutput("go version go1.15.2 linux/amd64\n"))
+	assert.True(t, ok)
+	assert.Equal(t, 15, minor)
+
+	assert.Equal(t, "", parseGoVersionOutput("unexpected"))
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+)
+
+const (
+	// EnvConfigKey is the environment variable of the key the embedded configuration is encrypted with, it is read
+	// when building and when running the app
+	EnvConfigKey = "AIFLOW_CONFIG_KEY"
+)
+
+// embeddedConfig is the configuration embedded in the app
+type embeddedConfig struct {
+	AIflowJSON     string // AIflow.json as a Go byte slice literal
+	EngineJSON     string // engine.json as a Go byte slice literal
+	AIflowDigest   string // sha256 of AIflow.json
+	Compressed     bool
+	Encrypted      bool
+	NewMain        bool
+	EnvConfigKey   string
+	FileAIflowJSON string
+}
+
+// createEmbeddedAppGoFile embeds AIflow.json and engine.json, with the overlays of the environment applied if specified
+func createEmbeddedAppGoFile(project common.AppProject, options common.BuildOptions) error {
+
+	embedSrcPath := filepath.Join(project.SrcDir(), fileEmbeddedAppGo)
+
+	logf(project, "Embedding configuration in application...\n")
+
+	AIflowJSON, err := RenderAppDescriptor(project, options.Env)
+	if err != nil {
+		return err
+	}
+
+	if options.Env != "" {
+		logf(project, "Applied the overlays of environment '%s'\n", options.Env)
+
+		err = validateAppDescriptor(project, AIflowJSON)
+		if err != nil {
+			return fmt.Errorf("environment '%s': %v", options.Env, err)
+		}
+	}
+
+	engineJSON, err := RenderEngineDescriptor(project, options.Env)
+	if err != nil {
+		return err
+	}
+
+	if !options.EncryptConfig {
+		warnEmbeddedSecrets(project, fileAIflowJson, AIflowJSON)
+		warnEmbeddedSecrets(project, fileEngineJson, engineJSON)
+	}
+
+	var key []byte
+	if options.EncryptConfig {
+		key, err = embeddedConfigKey(common.ProjectSession(project))
+		if err != nil {
+			return err
+		}
+	}
+
+	data := &embeddedConfig{
+		AIflowDigest:   configDigest(AIflowJSON),
+		Compressed:     options.CompressConfig,
+		Encrypted:      options.EncryptConfig,
+		NewMain:        isNewMain(project),
+		EnvConfigKey:   EnvConfigKey,
+		FileAIflowJSON: fileAIflowJson,
+	}
+
+	encoded, err := encodeConfig(AIflowJSON, options.CompressConfig, key, options.Reproducible)
+	if err != nil {
+		return err
+	}
+	data.AIflowJSON = goBytesLiteral(encoded)
+
+	encoded, err = encodeConfig(engineJSON, options.CompressConfig, key, options.Reproducible)
+	if err != nil {
+		return err
+	}
+	data.EngineJSON = goBytesLiteral(encoded)
+
+	f, err := os.Create(embedSrcPath)
+	if err != nil {
+		return err
+	}
+	RenderTemplate(f, tplEmbeddedAppGoFile, data)
+	_ = f.Close()
+
+	return nil
+}
+
+// embeddedConfigKey returns the AES-256 key derived from the key of the environment of the session
+func embeddedConfigKey(session *common.Session) ([]byte, error) {
+
+	key := session.Getenv(EnvConfigKey)
+	if key == "" {
+		return nil, fmt.Errorf("the key of the encrypted configuration must be set with the %s environment variable", EnvConfigKey)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return sum[:], nil
+}
+
+// encodeConfig compresses and encrypts the configuration, the data is gzipped then encrypted with AES-GCM, the nonce
+// preceding the encrypted data.  A deterministic nonce, derived from the key and data, is used for reproducible builds
+func encodeConfig(config string, compress bool, key []byte, deterministic bool) ([]byte, error) {
+
+	if config == "" {
+		return nil, nil
+	}
+
+	data := []byte(config)
+
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+
+	if key != nil {
+		gcm, err := newConfigCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, gcm.NonceSize())
+		if deterministic {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(data)
+			copy(nonce, mac.Sum(nil))
+		} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		data = gcm.Seal(nonce, nonce, data, nil)
+	}
+
+	return data, nil
+}
+
+// decodeConfig reverts encodeConfig, it mirrors the decoding done by the generated embeddedapp.go
+func decodeConfig(data []byte, compressed bool, key []byte) (string, error) {
+
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	if key != nil {
+		gcm, err := newConfigCipher(key)
+		if err != nil {
+			return "", err
+		}
+		if len(data) < gcm.NonceSize() {
+			return "", fmt.Errorf("invalid encrypted configuration")
+		}
+		data, err = gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+		if err != nil {
+			return "", fmt.Errorf("unable to decrypt configuration: %v", err)
+		}
+	}
+
+	if compressed {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		data, err = ioutil.ReadAll(zr)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(data), nil
+}
+
+func newConfigCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// goBytesLiteral returns the Go literal of a byte slice
+func goBytesLiteral(data []byte) string {
+
+	if len(data) == 0 {
+		return "[]byte(nil)"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[]byte{")
+	for i, b := range data {
+		if i%16 == 0 {
+			sb.WriteString("\n\t")
+		} else {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "0x%02x,", b)
+	}
+	sb.WriteString("\n}")
+
+	return sb.String()
+}
+
+func configDigest(config string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(config)))
+}
+
+var embeddedDigestPattern = regexp.MustCompile(`(?m)^// ` + regexp.QuoteMeta(fileAIflowJson) + ` sha256: ([0-9a-f]{64})$`)
+
+// embeddedAppDigest returns the sha256 of the AIflow.json embedded by an embeddedapp.go, if recorded
+func embeddedAppDigest(embeddedAppGo []byte) string {
+	if match := embeddedDigestPattern.FindSubmatch(embeddedAppGo); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+var tplEmbeddedAppGoFile = `// Do not change this file, it has been generated using AIflow-cli
+// If you change it and rebuild the application your changes might get lost
+package main
+
+import (
+{{- if .Compressed}}
+	"bytes"
+	"compress/gzip"
+{{- end}}
+{{- if .Encrypted}}
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+{{- end}}
+	"fmt"
+{{- if .Compressed}}
+	"io/ioutil"
+{{- end}}
+	"os"
+)
+
+// embedded AIflow app descriptor file
+// {{.FileAIflowJSON}} sha256: {{.AIflowDigest}}
+var AIflowJSON = {{.AIflowJSON}}
+{{- if .NewMain}}
+
+var engineJSON = {{.EngineJSON}}
+{{- end}}
+
+// filters of the embedded AIflow.json, they are registered by the variable initializers of the other generated files
+// which all run before this init
+var embeddedConfigFilters []func(string) string
+
+func init () {
+	cfgJson = decodeEmbeddedConfig(AIflowJSON)
+	for _, filter := range embeddedConfigFilters {
+		cfgJson = filter(cfgJson)
+	}
+{{- if .NewMain}}
+	cfgEngine = decodeEmbeddedConfig(engineJSON)
+{{- end}}
+}
+
+func decodeEmbeddedConfig(data []byte) string {
+
+	if len(data) == 0 {
+		return ""
+	}
+
+	var err error
+{{- if .Encrypted}}
+
+	data, err = decryptEmbeddedConfig(data, os.Getenv("{{.EnvConfigKey}}"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to decrypt the embedded configuration: %v\n", err)
+		os.Exit(1)
+	}
+{{- end}}
+{{- if .Compressed}}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err == nil {
+		data, err = ioutil.ReadAll(zr)
+	}
+{{- end}}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read the embedded configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	return string(data)
+}
+{{- if .Encrypted}}
+
+func decryptEmbeddedConfig(data []byte, key string) ([]byte, error) {
+
+	if key == "" {
+		return nil, errors.New("{{.EnvConfigKey}} isn't set")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid encrypted configuration")
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+{{- end}}
+`
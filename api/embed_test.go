@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeConfig(t *testing.T) {
+
+	config := "{\"name\": \"`app`\"}"
+	key := make([]byte, 32)
+
+	for _, compress := range []bool{false, true} {
+		for _, k := range [][]byte{nil, key} {
+			encoded, err := encodeConfig(config, compress, k, false)
+			assert.Nil(t, err)
+
+			decoded, err := decodeConfig(encoded, compress, k)
+			assert.Nil(t, err)
+			assert.Equal(t, config, decoded)
+		}
+	}
+
+	encoded, err := encodeConfig(config, false, key, false)
+	assert.Nil(t, err)
+	_, err = decodeConfig(encoded, false, make([]byte, 16))
+	assert.NotNil(t, err)
+
+	reproduced, err := encodeConfig(config, true, key, true)
+	assert.Nil(t, err)
+	encoded, err = encodeConfig(config, true, key, true)
+	assert.Nil(t, err)
+	assert.Equal(t, reproduced, encoded)
+
+	encoded, err = encodeConfig("", true, key, false)
+	assert.Nil(t, err)
+	assert.Nil(t, encoded)
+}
+
+func TestEmbeddedAppGoFile(t *testing.T) {
+
+	for _, data := range []*embeddedConfig{
+		{NewMain: true},
+		{Compressed: true},
+		{Encrypted: true, NewMain: true},
+		{Compressed: true, Encrypted: true, NewMain: true},
+	} {
+		data.AIflowJSON = goBytesLiteral([]byte("{\"name\": \"`app`\"}"))
+		data.EngineJSON = goBytesLiteral(nil)
+		data.AIflowDigest = configDigest("{}")
+		data.EnvConfigKey = EnvConfigKey
+		data.FileAIflowJSON = fileAIflowJson
+
+		var buf bytes.Buffer
+		RenderTemplate(&buf, tplEmbeddedAppGoFile, data)
+
+		_, err := parser.ParseFile(token.NewFileSet(), fileEmbeddedAppGo, buf.Bytes(), 0)
+		assert.Nil(t, err)
+		assert.Equal(t, configDigest("{}"), embeddedAppDigest(buf.Bytes()))
+	}
+}
@@ -0,0 +1,472 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+type engineSettingKind int
+
+const (
+	engineString engineSettingKind = iota
+	engineBool
+	engineInt
+)
+
+type engineSetting struct {
+	kind    engineSettingKind
+	allowed []string
+}
+
+// the engine.json settings that can be set, keys of nested objects are dotted
+var engineSettings = map[string]engineSetting{
+	"name":                 {kind: engineString},
+	"description":          {kind: engineString},
+	"stopEngineOnError":    {kind: engineBool},
+	"runnerType":           {kind: engineString, allowed: []string{"POOLED", "DIRECT"}},
+	"runner.numWorkers":    {kind: engineInt},
+	"runner.workQueueSize": {kind: engineInt},
+	"log.level":            {kind: engineString, allowed: []string{"DEBUG", "INFO", "WARN", "ERROR"}},
+	"log.format":           {kind: engineString, allowed: []string{"TEXT", "JSON"}},
+}
+
+// EngineSettingKeys returns the engine.json settings that can be set
+func EngineSettingKeys() []string {
+	var keys []string
+	for key := range engineSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// InitEngineConfig creates the engine.json of the project, an existing engine.json is only replaced with force
+func InitEngineConfig(project common.AppProject, force bool) error {
+
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	if util.FileExists(engineJsonPath) && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", fileEngineJson)
+	}
+
+	engineObj := map[string]interface{}{
+		"name":              project.Name(),
+		"type":              "AIflow:engine",
+		"imports":           []interface{}{},
+		"stopEngineOnError": true,
+		"runnerType":        "POOLED",
+		"services":          []interface{}{},
+	}
+
+	err := writeEngineDescriptorMap(project, engineObj)
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Created %s\n", fileEngineJson)
+
+	return nil
+}
+
+// ShowEngineConfig prints the engine.json of the project and the problems of its services
+func ShowEngineConfig(project common.AppProject) error {
+
+	engineObj, err := readEngineDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(engineObj, "", "  ")
+	if err != nil {
+		return err
+	}
+	printf(project, "%s\n", buf)
+
+	for _, service := range getObjects(engineObj, "services") {
+		ref, _ := service["ref"].(string)
+		if _, err := validateEngineService(project, engineObj, ref, service["settings"]); err != nil {
+			printf(project, "Warning: service '%s': %v\n", ref, err)
+		}
+	}
+
+	return nil
+}
+
+// SetEngineSetting sets a setting of engine.json, with actionRef the setting is an action setting of that action
+func SetEngineSetting(project common.AppProject, actionRef, key, value string) error {
+
+	engineObj, err := readEngineDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	if actionRef != "" {
+		actionRef, err = resolveActionRef(project, actionRef)
+		if err != nil {
+			return err
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t.#/") {
+			return fmt.Errorf("invalid action setting '%s'", key)
+		}
+
+		actionSettings, ok := engineObj["actionSettings"].(map[string]interface{})
+		if !ok {
+			actionSettings = make(map[string]interface{})
+			engineObj["actionSettings"] = actionSettings
+		}
+		settings, ok := actionSettings[actionRef].(map[string]interface{})
+		if !ok {
+			settings = make(map[string]interface{})
+			actionSettings[actionRef] = settings
+		}
+		settings[key] = parseSettingValue(value)
+
+		return writeEngineDescriptorMap(project, engineObj)
+	}
+
+	setting, ok := engineSettings[key]
+	if !ok {
+		return fmt.Errorf("unknown engine setting '%s', the settings are: %s", key, strings.Join(EngineSettingKeys(), ", "))
+	}
+
+	val, err := parseEngineSetting(setting, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for '%s': %v", key, err)
+	}
+
+	obj := engineObj
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := obj[part].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			obj[part] = sub
+		}
+		obj = sub
+	}
+	obj[parts[len(parts)-1]] = val
+
+	return writeEngineDescriptorMap(project, engineObj)
+}
+
+// AddEngineService installs a service contribution and adds it to the services of engine.json, the service
+// and its settings are validated against the descriptor of the contribution
+func AddEngineService(project common.AppProject, pkg string, settings map[string]string) error {
+
+	engineObj, err := readEngineDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	serviceImport, err := util.ParseImport(pkg)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range getObjects(engineObj, "services") {
+		if ref, _ := service["ref"].(string); matchesEngineRef(engineObj, ref, serviceImport) {
+			return fmt.Errorf("service '%s' is already configured", serviceImport.GoImportPath())
+		}
+	}
+
+	if existing, err := resolveEngineRef(engineObj, "#"+serviceImport.CanonicalAlias()); err == nil && existing.GoImportPath() != serviceImport.GoImportPath() {
+		return fmt.Errorf("alias '%s' is already used by import '%s', give the service a distinct alias (ex. \"myalias %s\")",
+			serviceImport.CanonicalAlias(), existing.CanonicalImport(), serviceImport.GoImportPath())
+	}
+
+	err = project.AddImports(false, false, serviceImport)
+	if err != nil {
+		return err
+	}
+
+	serviceSettings := make(map[string]interface{})
+	for name, value := range settings {
+		serviceSettings[name] = parseSettingValue(value)
+	}
+
+	if !engineImportExists(engineObj, serviceImport) {
+		imports, _ := engineObj["imports"].([]interface{})
+		engineObj["imports"] = append(imports, serviceImport.CanonicalImport())
+	}
+
+	ref := "#" + serviceImport.CanonicalAlias()
+	desc, err := validateEngineService(project, engineObj, ref, serviceSettings)
+	if err != nil {
+		if !appUsesImport(project, serviceImport) {
+			_ = project.RemoveImports(serviceImport.GoImportPath())
+		}
+		return err
+	}
+
+	service := map[string]interface{}{"ref": ref, "enabled": true}
+	if len(serviceSettings) > 0 {
+		service["settings"] = serviceSettings
+	}
+	services, _ := engineObj["services"].([]interface{})
+	engineObj["services"] = append(services, service)
+
+	err = writeEngineDescriptorMap(project, engineObj)
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Installed service: %s\n", serviceImport)
+
+	for _, attr := range desc.Settings {
+		if _, ok := serviceSettings[attr.Name]; !ok && attr.Required && attr.Value == nil {
+			printf(project, "Warning: required setting '%s' of service '%s' isn't set\n", attr.Name, desc.Name)
+		}
+	}
+
+	return nil
+}
+
+// RemoveEngineService removes a service from engine.json, its import is removed when no longer used
+func RemoveEngineService(project common.AppProject, ref string) error {
+
+	engineObj, err := readEngineDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	serviceImport, err := resolveEngineRef(engineObj, ref)
+	if err != nil {
+		return err
+	}
+
+	var services []interface{}
+	removed := false
+	for _, service := range getObjects(engineObj, "services") {
+		if serviceRef, _ := service["ref"].(string); matchesEngineRef(engineObj, serviceRef, serviceImport) {
+			removed = true
+			continue
+		}
+		services = append(services, service)
+	}
+
+	if !removed {
+		return fmt.Errorf("service '%s' not found in %s", ref, fileEngineJson)
+	}
+
+	if services == nil {
+		services = []interface{}{}
+	}
+	engineObj["services"] = services
+
+	removeImportFromMap(engineObj, serviceImport)
+
+	err = writeEngineDescriptorMap(project, engineObj)
+	if err != nil {
+		return err
+	}
+
+	if !appUsesImport(project, serviceImport) {
+		err = project.RemoveImports(serviceImport.GoImportPath())
+		if err != nil {
+			return err
+		}
+	}
+
+	printf(project, "Removed service: %s\n", serviceImport.GoImportPath())
+
+	return nil
+}
+
+// resolveActionRef returns the import path of the action referred to by an alias or import path, the action
+// must be imported by the app
+func resolveActionRef(project common.AppProject, ref string) (string, error) {
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), false)
+	if err != nil {
+		return "", err
+	}
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("empty action ref")
+	}
+
+	var actionImport util.Import
+	if ref[0] == '#' {
+		details, err := findAliasedImport(appImports, ref[1:], "")
+		if err != nil {
+			return "", err
+		}
+		actionImport = details.Imp
+	} else {
+		refImport, err := util.ParseImport(ref)
+		if err != nil {
+			return "", err
+		}
+		for _, appImport := range appImports.GetAllImports() {
+			if appImport.GoImportPath() == refImport.GoImportPath() {
+				actionImport = appImport
+			}
+		}
+		if actionImport == nil {
+			return "", fmt.Errorf("action '%s' isn't imported by the app", ref)
+		}
+	}
+
+	// the type is only checked when the descriptor of the contribution is available
+	desc, err := util.GetContribDescriptorFromImport(project.DepManager(), actionImport)
+	if err == nil && desc != nil && desc.GetContribType() != "action" {
+		return "", fmt.Errorf("'%s' is a %s, not an action", actionImport.GoImportPath(), desc.GetContribType())
+	}
+
+	return actionImport.GoImportPath(), nil
+}
+
+// validateEngineService checks that the service ref refers to a service contribution and that the settings
+// are declared by its descriptor
+func validateEngineService(project common.AppProject, engineObj map[string]interface{}, ref string, settings interface{}) (*util.AIflowContribDescriptor, error) {
+
+	serviceImport, err := resolveEngineRef(engineObj, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := util.GetContribDescriptorFromImport(project.DepManager(), serviceImport)
+	if err != nil {
+		return nil, err
+	}
+	if desc == nil {
+		return nil, fmt.Errorf("no descriptor found for '%s'", serviceImport.GoImportPath())
+	}
+	if desc.GetContribType() != "service" {
+		return nil, fmt.Errorf("'%s' is a %s, not a service", serviceImport.GoImportPath(), desc.GetContribType())
+	}
+
+	declared := make(map[string]bool)
+	for _, attr := range desc.Settings {
+		declared[attr.Name] = true
+	}
+
+	settingsMap, _ := settings.(map[string]interface{})
+	for name := range settingsMap {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown setting '%s' for service '%s'", name, desc.Name)
+		}
+	}
+
+	return desc, nil
+}
+
+// resolveEngineRef returns the import referred to by a service ref, an alias ref must refer to an engine.json import
+func resolveEngineRef(engineObj map[string]interface{}, ref string) (util.Import, error) {
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("empty service ref")
+	}
+
+	if ref[0] != '#' {
+		return util.ParseImport(ref)
+	}
+
+	imports, _ := engineObj["imports"].([]interface{})
+	for _, val := range imports {
+		strVal, _ := val.(string)
+		if imp, err := util.ParseImport(strVal); err == nil && imp.CanonicalAlias() == ref[1:] {
+			return imp, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no import found in %s with alias '%s'", fileEngineJson, ref[1:])
+}
+
+func matchesEngineRef(engineObj map[string]interface{}, ref string, imp util.Import) bool {
+	refImport, err := resolveEngineRef(engineObj, ref)
+	return err == nil && refImport.GoImportPath() == imp.GoImportPath()
+}
+
+func engineImportExists(engineObj map[string]interface{}, imp util.Import) bool {
+	imports, _ := engineObj["imports"].([]interface{})
+	for _, val := range imports {
+		strVal, _ := val.(string)
+		if existing, err := util.ParseImport(strVal); err == nil && existing.GoImportPath() == imp.GoImportPath() {
+			return true
+		}
+	}
+	return false
+}
+
+func appUsesImport(project common.AppProject, imp util.Import) bool {
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), false)
+	if err != nil {
+		// assume it is used, so the Go import isn't removed
+		return true
+	}
+
+	for _, appImport := range appImports.GetAllImports() {
+		if appImport.GoImportPath() == imp.GoImportPath() {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEngineSetting(setting engineSetting, value string) (interface{}, error) {
+
+	switch setting.kind {
+	case engineBool:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case engineInt:
+		return strconv.Atoi(strings.TrimSpace(value))
+	}
+
+	if len(setting.allowed) > 0 {
+		for _, allowed := range setting.allowed {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(setting.allowed, ", "))
+	}
+
+	return value, nil
+}
+
+// parseSettingValue parses a JSON value, anything else is kept as a string
+func parseSettingValue(value string) interface{} {
+	var val interface{}
+	if err := json.Unmarshal([]byte(value), &val); err == nil {
+		return val
+	}
+	return value
+}
+
+func readEngineDescriptorMap(project common.AppProject) (map[string]interface{}, error) {
+
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	if !util.FileExists(engineJsonPath) {
+		return nil, fmt.Errorf("%s not found, create it with 'AIflow engine init'", fileEngineJson)
+	}
+
+	buf, err := ioutil.ReadFile(engineJsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var engineObj map[string]interface{}
+	err = json.Unmarshal(buf, &engineObj)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", fileEngineJson, err)
+	}
+
+	return engineObj, nil
+}
+
+// writeEngineDescriptorMap writes the descriptor keeping the key order and formatting of the current engine.json
+func writeEngineDescriptorMap(project common.AppProject, engineObj map[string]interface{}) error {
+	return writeJsonFileInPlace(filepath.Join(project.Dir(), fileEngineJson), engineObj)
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var engineAppJson = `{
+  "name": "temp",
+  "type": "AIflow:app",
+  "imports": [
+    "github.com/r2d2-ai/aiflow/action/flow",
+    "github.com/r2d2-ai/aiflow/trigger/net/rest",
+    "github.com/r2d2-ai/aiflow/services/metrics"
+  ]
+}`
+
+var engineDescriptors = map[string]string{
+	"github.com/r2d2-ai/aiflow/action/flow":      `{"name": "flow", "type": "AIflow:action"}`,
+	"github.com/r2d2-ai/aiflow/trigger/net/rest": `{"name": "rest", "type": "AIflow:trigger"}`,
+	"github.com/r2d2-ai/aiflow/services/metrics": `{"name": "metrics", "type": "AIflow:service", "settings": [{"name": "port", "type": "integer", "required": true}]}`,
+	"github.com/r2d2-ai/aiflow/services/health":  `{"name": "health", "type": "AIflow:service", "settings": [{"name": "path", "type": "string"}]}`,
+}
+
+// engineTestProject records the Go imports added and removed, the contributions are resolved from the test descriptors
+type engineTestProject struct {
+	common.AppProject
+	contribDir string
+	added      []string
+	removed    []string
+}
+
+func (p *engineTestProject) AddImports(ignoreError bool, addToJson bool, imports ...util.Import) error {
+	for _, imp := range imports {
+		p.added = append(p.added, imp.GoImportPath())
+	}
+	return nil
+}
+
+func (p *engineTestProject) RemoveImports(imports ...string) error {
+	p.removed = append(p.removed, imports...)
+	return nil
+}
+
+func (p *engineTestProject) DepManager() util.DepManager {
+	return &engineTestDepManager{contribDir: p.contribDir}
+}
+
+type engineTestDepManager struct {
+	util.DepManager
+	contribDir string
+}
+
+func (m *engineTestDepManager) GetPath(imp util.Import) (string, error) {
+	return filepath.Join(m.contribDir, filepath.FromSlash(imp.GoImportPath())), nil
+}
+
+func newEngineTestProject(t *testing.T) (*engineTestProject, func()) {
+
+	tempDir := newWorkspaceTestDir(t, "app")
+	appDir := filepath.Join(tempDir, "app")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, fileAIflowJson), []byte(engineAppJson), 0644))
+
+	contribDir := filepath.Join(tempDir, "contribs")
+	for path, descriptor := range engineDescriptors {
+		dir := filepath.Join(contribDir, filepath.FromSlash(path))
+		assert.Nil(t, os.MkdirAll(dir, os.ModePerm))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "descriptor.json"), []byte(descriptor), 0644))
+	}
+
+	project := &engineTestProject{AppProject: NewAppProject(appDir), contribDir: contribDir}
+	assert.Nil(t, InitEngineConfig(project, false))
+
+	return project, func() { os.RemoveAll(tempDir) }
+}
+
+func readTestEngineJson(t *testing.T, project common.AppProject) map[string]interface{} {
+	engineObj, err := readEngineDescriptorMap(project)
+	assert.Nil(t, err)
+	return engineObj
+}
+
+func TestSetEngineSetting(t *testing.T) {
+
+	project, cleanup := newEngineTestProject(t)
+	defer cleanup()
+
+	assert.Nil(t, SetEngineSetting(project, "", "runner.numWorkers", " 5"))
+	assert.Nil(t, SetEngineSetting(project, "", "log.level", "debug"))
+	assert.Nil(t, SetEngineSetting(project, "", "stopEngineOnError", "false"))
+
+	engineObj := readTestEngineJson(t, project)
+	assert.Equal(t, map[string]interface{}{"numWorkers": float64(5)}, engineObj["runner"])
+	assert.Equal(t, map[string]interface{}{"level": "DEBUG"}, engineObj["log"])
+	assert.Equal(t, false, engineObj["stopEngineOnError"])
+
+	err := SetEngineSetting(project, "", "log.level", "TRACE")
+	assert.EqualError(t, err, "invalid value for 'log.level': must be one of DEBUG, INFO, WARN, ERROR")
+	assert.NotNil(t, SetEngineSetting(project, "", "runner.numWorkers", "many"))
+	assert.Contains(t, SetEngineSetting(project, "", "runner.size", "5").Error(), "unknown engine setting 'runner.size'")
+
+	// action settings are recorded by the import path of the action
+	assert.Nil(t, SetEngineSetting(project, "#flow", "stepQueueSize", "100"))
+	assert.Nil(t, SetEngineSetting(project, "github.com/r2d2-ai/aiflow/action/flow", "mode", "debugger"))
+
+	engineObj = readTestEngineJson(t, project)
+	actionSettings := engineObj["actionSettings"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"stepQueueSize": float64(100), "mode": "debugger"}, actionSettings["github.com/r2d2-ai/aiflow/action/flow"])
+
+	assert.EqualError(t, SetEngineSetting(project, "#rest", "mode", "x"), "'github.com/r2d2-ai/aiflow/trigger/net/rest' is a trigger, not an action")
+	assert.EqualError(t, SetEngineSetting(project, "#missing", "mode", "x"), "no import found with alias 'missing'")
+	assert.EqualError(t, SetEngineSetting(project, "github.com/r2d2-ai/aiflow/action/other", "mode", "x"), "action 'github.com/r2d2-ai/aiflow/action/other' isn't imported by the app")
+	assert.EqualError(t, SetEngineSetting(project, "#flow", "step.mode", "x"), "invalid action setting 'step.mode'")
+}
+
+func TestSetEngineSettingFormatting(t *testing.T) {
+
+	project, cleanup := newEngineTestProject(t)
+	defer cleanup()
+
+	// an edit keeps the key order, indentation and characters of engine.json
+	engineJson := "{\n    \"type\": \"AIflow:engine\",\n    \"name\": \"<app> & co\",\n    \"log\": {\n        \"level\": \"INFO\"\n    }\n}\n"
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	assert.Nil(t, ioutil.WriteFile(engineJsonPath, []byte(engineJson), 0644))
+
+	assert.Nil(t, SetEngineSetting(project, "", "log.level", "debug"))
+
+	buf, err := ioutil.ReadFile(engineJsonPath)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Replace(engineJson, "INFO", "DEBUG", 1), string(buf))
+}
+
+func TestResolveEngineRef(t *testing.T) {
+
+	engineObj := map[string]interface{}{
+		"imports": []interface{}{"github.com/r2d2-ai/aiflow/services/metrics", "hc github.com/r2d2-ai/aiflow/services/health"},
+	}
+
+	imp, err := resolveEngineRef(engineObj, "#hc")
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/r2d2-ai/aiflow/services/health", imp.GoImportPath())
+
+	imp, err = resolveEngineRef(engineObj, " #metrics ")
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/r2d2-ai/aiflow/services/metrics", imp.GoImportPath())
+
+	imp, err = resolveEngineRef(engineObj, "github.com/r2d2-ai/aiflow/services/other")
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/r2d2-ai/aiflow/services/other", imp.GoImportPath())
+
+	_, err = resolveEngineRef(engineObj, "#health")
+	assert.EqualError(t, err, "no import found in engine.json with alias 'health'")
+
+	_, err = resolveEngineRef(engineObj, " ")
+	assert.EqualError(t, err, "empty service ref")
+
+	assert.False(t, matchesEngineRef(engineObj, "#hc", imp))
+}
+
+func TestAddEngineService(t *testing.T) {
+
+	project, cleanup := newEngineTestProject(t)
+	defer cleanup()
+
+	// an invalid service is rolled back, the Go import is only removed when the app doesn't use it
+	err := AddEngineService(project, "github.com/r2d2-ai/aiflow/services/health", map[string]string{"port": "8080"})
+	assert.EqualError(t, err, "unknown setting 'port' for service 'health'")
+	assert.Equal(t, []string{"github.com/r2d2-ai/aiflow/services/health"}, project.added)
+	assert.Equal(t, []string{"github.com/r2d2-ai/aiflow/services/health"}, project.removed)
+
+	err = AddEngineService(project, "github.com/r2d2-ai/aiflow/trigger/net/rest", nil)
+	assert.EqualError(t, err, "'github.com/r2d2-ai/aiflow/trigger/net/rest' is a trigger, not a service")
+	assert.Len(t, project.removed, 1)
+
+	engineObj := readTestEngineJson(t, project)
+	assert.Empty(t, engineObj["imports"])
+	assert.Empty(t, engineObj["services"])
+
+	assert.Nil(t, AddEngineService(project, "github.com/r2d2-ai/aiflow/services/health", map[string]string{"path": "/health"}))
+
+	engineObj = readTestEngineJson(t, project)
+	assert.Equal(t, []interface{}{"github.com/r2d2-ai/aiflow/services/health"}, engineObj["imports"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"ref": "#health", "enabled": true, "settings": map[string]interface{}{"path": "/health"}}}, engineObj["services"])
+
+	assert.EqualError(t, AddEngineService(project, "github.com/r2d2-ai/aiflow/services/health", nil), "service 'github.com/r2d2-ai/aiflow/services/health' is already configured")
+}
+
+func TestRemoveEngineService(t *testing.T) {
+
+	project, cleanup := newEngineTestProject(t)
+	defer cleanup()
+
+	assert.Nil(t, AddEngineService(project, "github.com/r2d2-ai/aiflow/services/health", nil))
+	assert.Nil(t, AddEngineService(project, "github.com/r2d2-ai/aiflow/services/metrics", map[string]string{"port": "9090"}))
+
+	// the Go import of a service also imported by the app is kept
+	assert.Nil(t, RemoveEngineService(project, "#metrics"))
+	assert.Empty(t, project.removed)
+
+	assert.Nil(t, RemoveEngineService(project, "github.com/r2d2-ai/aiflow/services/health"))
+	assert.Equal(t, []string{"github.com/r2d2-ai/aiflow/services/health"}, project.removed)
+
+	engineObj := readTestEngineJson(t, project)
+	assert.Empty(t, engineObj["imports"])
+	assert.Equal(t, []interface{}{}, engineObj["services"])
+
+	assert.EqualError(t, RemoveEngineService(project, "#health"), "no import found in engine.json with alias 'health'")
+
+	buf, err := json.Marshal(engineObj)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(buf), "services/metrics")
+}
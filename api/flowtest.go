@@ -0,0 +1,512 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	fileFlowTestGo  = "aiflowtest_test.go"
+	dirTests        = "tests"
+	flowActionRef   = AIflowCoreRepo + "/action/flow"
+	flowTestRunName = "TestAIflowFlows"
+
+	envFlowTestApp     = "AIFLOW_TEST_APP"
+	envFlowTestCases   = "AIFLOW_TEST_CASES"
+	envFlowTestResults = "AIFLOW_TEST_RESULTS"
+	envFlowTestRef     = "AIFLOW_TEST_FLOW_REF"
+)
+
+// FlowTestCase is a flow unit test, mocked activity outputs are keyed by task id
+type FlowTestCase struct {
+	Name        string                            `json:"name"`
+	FlowURI     string                            `json:"flowURI"`
+	Input       map[string]interface{}            `json:"input,omitempty"`
+	Mocks       map[string]map[string]interface{} `json:"mocks,omitempty"`
+	Expected    map[string]interface{}            `json:"expected,omitempty"`
+	ExpectError bool                              `json:"expectError,omitempty"`
+
+	File string `json:"file,omitempty"`
+}
+
+// FlowTestResult is the result of running a FlowTestCase
+type FlowTestResult struct {
+	Case     *FlowTestCase
+	Outputs  map[string]interface{}
+	Error    string
+	Duration time.Duration
+	Failures []string
+}
+
+func (r *FlowTestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// FlowTestReport is the result of running all the flow test cases of an app
+type FlowTestReport struct {
+	App      string
+	Results  []*FlowTestResult
+	Duration time.Duration
+}
+
+func (r *FlowTestReport) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// flowTestRun is the raw result written by the generated test harness
+type flowTestRun struct {
+	Name     string                 `json:"name"`
+	Outputs  map[string]interface{} `json:"outputs"`
+	Error    string                 `json:"error"`
+	Duration int64                  `json:"durationNs"`
+}
+
+// LoadFlowTestCases loads the test cases from the specified files, a file contains a single test case or an
+// array of test cases. If no files are specified the 'tests/*.json' files of the project are used.
+func LoadFlowTestCases(project common.AppProject, files ...string) ([]*FlowTestCase, error) {
+
+	if len(files) == 0 {
+		var err error
+		files, err = filepath.Glob(filepath.Join(project.Dir(), dirTests, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var cases []*FlowTestCase
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileCases []*FlowTestCase
+		if trimmed := strings.TrimSpace(string(buf)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(buf, &fileCases)
+		} else {
+			tc := &FlowTestCase{}
+			err = json.Unmarshal(buf, tc)
+			fileCases = append(fileCases, tc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse test case file '%s': %v", file, err)
+		}
+
+		base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		for i, tc := range fileCases {
+			tc.File = base
+			if tc.Name == "" {
+				tc.Name = fmt.Sprintf("%s_%d", base, i+1)
+			}
+			if tc.FlowURI == "" {
+				return nil, fmt.Errorf("test case '%s' in '%s' is missing the flowURI", tc.Name, file)
+			}
+			if !strings.HasPrefix(tc.FlowURI, resURIPrefix) {
+				tc.FlowURI = resURIPrefix + tc.FlowURI
+			}
+		}
+
+		cases = append(cases, fileCases...)
+	}
+
+	return cases, nil
+}
+
+// RunFlowTests runs the flow test cases using a generated Go test harness, the triggers of the app are not started
+func RunFlowTests(project common.AppProject, cases []*FlowTestCase) (*FlowTestReport, error) {
+
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no test cases found, add test case files to the '%s' directory", dirTests)
+	}
+
+	tempDir, err := ioutil.TempDir("", "aiflowtest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	casesFile := filepath.Join(tempDir, "cases.json")
+	resultsFile := filepath.Join(tempDir, "results.json")
+
+	buf, err := json.Marshal(cases)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(casesFile, buf, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	harnessFile := filepath.Join(project.SrcDir(), fileFlowTestGo)
+	err = ioutil.WriteFile(harnessFile, []byte(tplFlowTestGoFile), 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := util.DeleteFile(harnessFile); err != nil {
+			eprintf(project, "Unable to delete: %s\n", harnessFile)
+		}
+	}()
+
+	logf(project, "Running %d flow test cases...\n", len(cases))
+
+	cmd := exec.Command("go", "test", "-count=1", "-run", "^"+flowTestRunName+"$", ".")
+	cmd.Env = append(common.ProjectSession(project).Environ(),
+		envFlowTestApp+"="+filepath.Join(project.Dir(), fileAIflowJson),
+		envFlowTestCases+"="+casesFile,
+		envFlowTestResults+"="+resultsFile,
+		envFlowTestRef+"="+getFlowActionRef(project))
+
+	start := time.Now()
+	err = execCmd(project, cmd, project.SrcDir())
+	if err != nil {
+		return nil, fmt.Errorf("unable to run flow tests: %v", err)
+	}
+
+	buf, err = ioutil.ReadFile(resultsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*flowTestRun
+	err = json.Unmarshal(buf, &runs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FlowTestReport{App: project.Name(), Duration: time.Since(start)}
+	for i, tc := range cases {
+		if i >= len(runs) {
+			report.Results = append(report.Results, &FlowTestResult{Case: tc, Failures: []string{"test case was not run"}})
+			continue
+		}
+		report.Results = append(report.Results, evaluateFlowTest(tc, runs[i]))
+	}
+
+	return report, nil
+}
+
+// getFlowActionRef determines the ref of the flow action from the app imports
+func getFlowActionRef(project common.AppProject) string {
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return flowActionRef
+	}
+
+	details, err := findAliasedImport(appImports, "flow", "action")
+	if err != nil {
+		return flowActionRef
+	}
+
+	return details.Imp.GoImportPath()
+}
+
+func evaluateFlowTest(tc *FlowTestCase, run *flowTestRun) *FlowTestResult {
+
+	result := &FlowTestResult{Case: tc, Outputs: run.Outputs, Error: run.Error, Duration: time.Duration(run.Duration)}
+
+	if tc.ExpectError {
+		if run.Error == "" {
+			result.Failures = append(result.Failures, "expected an error but the flow completed")
+		}
+		return result
+	}
+
+	if run.Error != "" {
+		result.Failures = append(result.Failures, "flow failed: "+run.Error)
+		return result
+	}
+
+	result.Failures = append(result.Failures, compareFlowOutputs(tc.Expected, run.Outputs)...)
+
+	return result
+}
+
+// compareFlowOutputs compares the expected outputs with the actual outputs, outputs that aren't expected are ignored
+func compareFlowOutputs(expected, actual map[string]interface{}) []string {
+
+	var failures []string
+
+	for _, name := range sortedMapKeys(expected) {
+		actualVal, exists := actual[name]
+		if !exists {
+			failures = append(failures, fmt.Sprintf("output '%s' missing, expected %s", name, toJsonString(expected[name])))
+			continue
+		}
+
+		if !reflect.DeepEqual(normalizeJson(expected[name]), normalizeJson(actualVal)) {
+			failures = append(failures, fmt.Sprintf("output '%s' is %s, expected %s", name, toJsonString(actualVal), toJsonString(expected[name])))
+		}
+	}
+
+	return failures
+}
+
+// normalizeJson converts a value to its JSON decoded form, so numbers etc. can be compared
+func normalizeJson(val interface{}) interface{} {
+	buf, err := json.Marshal(val)
+	if err != nil {
+		return val
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(buf, &normalized); err != nil {
+		return val
+	}
+
+	return normalized
+}
+
+func toJsonString(val interface{}) string {
+	buf, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(buf)
+}
+
+// PrintFlowTestSummary prints a human readable summary of the test report
+func PrintFlowTestSummary(w io.Writer, report *FlowTestReport) {
+
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %s (%.3fs)\n", status, result.Case.Name, result.Duration.Seconds())
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "      %s\n", failure)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d tests, %d passed, %d failed (%.3fs)\n", len(report.Results), len(report.Results)-report.Failed(), report.Failed(), report.Duration.Seconds())
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name         `xml:"testsuite"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the test report in the JUnit XML format
+func WriteJUnitReport(w io.Writer, report *FlowTestReport) error {
+
+	suite := &junitTestSuite{Name: report.App, Tests: len(report.Results), Failures: report.Failed(), Time: fmt.Sprintf("%.3f", report.Duration.Seconds())}
+
+	for _, result := range report.Results {
+		tc := &junitTestCase{Name: result.Case.Name, Classname: report.App + "." + result.Case.File, Time: fmt.Sprintf("%.3f", result.Duration.Seconds())}
+		if !result.Passed() {
+			tc.Failure = &junitFailure{Message: result.Failures[0], Text: strings.Join(result.Failures, "\n")}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	buf, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, buf)
+	return err
+}
+
+var tplFlowTestGoFile = `// Do not change this file, it has been generated using AIflow-cli
+// It is removed once the flow tests have run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r2d2-ai/aiflow/action"
+	"github.com/r2d2-ai/aiflow/activity"
+	"github.com/r2d2-ai/aiflow/app"
+	"github.com/r2d2-ai/aiflow/engine/runner"
+)
+
+type aiflowTestCase struct {
+	Name    string                            ` + "`json:\"name\"`" + `
+	FlowURI string                            ` + "`json:\"flowURI\"`" + `
+	Input   map[string]interface{}            ` + "`json:\"input\"`" + `
+	Mocks   map[string]map[string]interface{} ` + "`json:\"mocks\"`" + `
+}
+
+type aiflowTestRun struct {
+	Name     string                 ` + "`json:\"name\"`" + `
+	Outputs  map[string]interface{} ` + "`json:\"outputs\"`" + `
+	Error    string                 ` + "`json:\"error\"`" + `
+	Duration int64                  ` + "`json:\"durationNs\"`" + `
+}
+
+// aiflowMockActivity replaces a mocked task's activity and returns the recorded outputs
+type aiflowMockActivity struct {
+	outputs map[string]interface{}
+}
+
+var aiflowMockMd = activity.ToMetadata()
+
+func (a *aiflowMockActivity) Metadata() *activity.Metadata {
+	return aiflowMockMd
+}
+
+func (a *aiflowMockActivity) Eval(ctx activity.Context) (bool, error) {
+	for name, val := range a.outputs {
+		if err := ctx.SetOutput(name, val); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func newAIflowMockActivity(ctx activity.InitContext) (activity.Activity, error) {
+	outputs, _ := ctx.Settings()["outputs"].(map[string]interface{})
+	return &aiflowMockActivity{outputs: outputs}, nil
+}
+
+func init() {
+	_ = activity.Register(&aiflowMockActivity{}, newAIflowMockActivity)
+}
+
+func TestAIflowFlows(t *testing.T) {
+
+	appFile := os.Getenv("` + envFlowTestApp + `")
+	if appFile == "" {
+		t.Skip("flow tests are run using 'AIflow test'")
+	}
+
+	appJson, err := ioutil.ReadFile(appFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casesJson, err := ioutil.ReadFile(os.Getenv("` + envFlowTestCases + `"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases []*aiflowTestCase
+	if err := json.Unmarshal(casesJson, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []*aiflowTestRun
+	for _, tc := range cases {
+		start := time.Now()
+		outputs, err := runAIflowTestCase(appJson, os.Getenv("` + envFlowTestRef + `"), tc)
+		run := &aiflowTestRun{Name: tc.Name, Outputs: outputs, Duration: int64(time.Since(start))}
+		if err != nil {
+			run.Error = err.Error()
+		}
+		runs = append(runs, run)
+	}
+
+	results, err := json.Marshal(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(os.Getenv("` + envFlowTestResults + `"), results, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runAIflowTestCase(appJson []byte, flowRef string, tc *aiflowTestCase) (map[string]interface{}, error) {
+
+	var appObj map[string]interface{}
+	if err := json.Unmarshal(appJson, &appObj); err != nil {
+		return nil, err
+	}
+
+	// triggers are never created, the flow is run directly
+	delete(appObj, "triggers")
+
+	mockRef := activity.GetRef(&aiflowMockActivity{})
+	resources, _ := appObj["resources"].([]interface{})
+	for _, res := range resources {
+		resMap, _ := res.(map[string]interface{})
+		resId, _ := resMap["id"].(string)
+		data, _ := resMap["data"].(map[string]interface{})
+		if !strings.HasPrefix(resId, "flow:") || data == nil {
+			continue
+		}
+
+		tasks, _ := data["tasks"].([]interface{})
+		for _, task := range tasks {
+			taskMap, _ := task.(map[string]interface{})
+			taskId, _ := taskMap["id"].(string)
+			if outputs, mocked := tc.Mocks[taskId]; mocked {
+				taskMap["activity"] = map[string]interface{}{"ref": mockRef, "settings": map[string]interface{}{"outputs": outputs}}
+			}
+		}
+	}
+
+	cfgJson, err := json.Marshal(appObj)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &app.Config{}
+	if err := json.Unmarshal(cfgJson, cfg); err != nil {
+		return nil, err
+	}
+
+	r := runner.NewDirect()
+	if _, err := app.New(cfg, r); err != nil {
+		return nil, err
+	}
+
+	factory := action.GetFactory(flowRef)
+	if factory == nil {
+		return nil, fmt.Errorf("flow action '%s' not installed", flowRef)
+	}
+
+	act, err := factory.New(&action.Config{Ref: flowRef, Settings: map[string]interface{}{"flowURI": tc.FlowURI}})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.RunAction(context.Background(), act, tc.Input)
+}
+`
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFlowTestCases(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "flowtest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	err = os.Mkdir(filepath.Join(tempDir, dirTests), os.ModePerm)
+	assert.Nil(t, err)
+
+	single := `{"flowURI": "flow:simple_flow", "input": {"in": "a"}, "expected": {"out": "a"}}`
+	multiple := `[{"name": "one", "flowURI": "res://flow:simple_flow"}, {"name": "two", "flowURI": "res://flow:other", "expectError": true}]`
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, dirTests, "a.json"), []byte(single), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, dirTests, "b.json"), []byte(multiple), 0644))
+
+	cases, err := LoadFlowTestCases(NewAppProject(tempDir))
+	assert.Nil(t, err)
+	assert.Len(t, cases, 3)
+	assert.Equal(t, "a_1", cases[0].Name)
+	assert.Equal(t, "res://flow:simple_flow", cases[0].FlowURI)
+	assert.Equal(t, "b", cases[2].File)
+	assert.True(t, cases[2].ExpectError)
+}
+
+func TestEvaluateFlowTest(t *testing.T) {
+	tc := &FlowTestCase{Name: "sum", File: "math", Expected: map[string]interface{}{"total": 3, "items": []interface{}{"a"}}}
+
+	result := evaluateFlowTest(tc, &flowTestRun{Outputs: map[string]interface{}{"total": 3.0, "items": []string{"a"}, "extra": true}})
+	assert.True(t, result.Passed())
+
+	result = evaluateFlowTest(tc, &flowTestRun{Outputs: map[string]interface{}{"total": 4}})
+	assert.Len(t, result.Failures, 2)
+
+	result = evaluateFlowTest(tc, &flowTestRun{Error: "boom"})
+	assert.False(t, result.Passed())
+
+	tc.ExpectError = true
+	result = evaluateFlowTest(tc, &flowTestRun{Error: "boom"})
+	assert.True(t, result.Passed())
+
+	report := &FlowTestReport{App: "myApp", Results: []*FlowTestResult{result, {Case: tc, Failures: []string{"failed <badly>"}}}}
+	var buf bytes.Buffer
+	assert.Nil(t, WriteJUnitReport(&buf, report))
+	assert.Contains(t, buf.String(), `<testsuite name="myApp" tests="2" failures="1"`)
+	assert.Contains(t, buf.String(), `classname="myApp.math"`)
+	assert.Contains(t, buf.String(), `failed &lt;badly&gt;`)
+}
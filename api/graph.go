@@ -0,0 +1,432 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	GraphNodeTrigger  = "trigger"
+	GraphNodeHandler  = "handler"
+	GraphNodeAction   = "action"
+	GraphNodeFlow     = "flow"
+	GraphNodeActivity = "activity"
+
+	GraphFormatDot     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatHtml    = "html"
+
+	resURIPrefix = "res://"
+)
+
+// graphKinds is the order in which the node kinds are laid out, left to right
+var graphKinds = []string{GraphNodeTrigger, GraphNodeHandler, GraphNodeAction, GraphNodeFlow, GraphNodeActivity}
+
+var graphColors = map[string]string{
+	GraphNodeTrigger:  "#f9d5a7",
+	GraphNodeHandler:  "#fbe8c8",
+	GraphNodeAction:   "#c9e4f6",
+	GraphNodeFlow:     "#cdeccd",
+	GraphNodeActivity: "#ececec",
+}
+
+// GraphNode is a trigger, handler, action, flow or activity task of the app
+type GraphNode struct {
+	Id    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// GraphEdge connects two nodes, a link is a link between two tasks of a flow
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Link bool   `json:"link,omitempty"`
+}
+
+// AppGraph is the topology of an app: triggers -> handlers -> actions -> flows -> activity tasks
+type AppGraph struct {
+	Name  string       `json:"name"`
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GetAppGraph builds the topology graph of the project's app
+func GetAppGraph(project common.AppProject) (*AppGraph, error) {
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return nil, err
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		// the graph can still be built, the nodes just won't have contribution names
+		logf(project, "Unable to resolve contributions: %v\n", err)
+		appImports = nil
+	}
+
+	gb := &graphBuilder{refResolver: refResolver{project: project, appImports: appImports}}
+	return gb.build(appObj), nil
+}
+
+// WriteAppGraph renders the graph in the specified format
+func WriteAppGraph(w io.Writer, graph *AppGraph, format string) error {
+	switch strings.ToLower(format) {
+	case "", GraphFormatDot:
+		writeGraphDot(w, graph)
+	case GraphFormatMermaid:
+		writeGraphMermaid(w, graph)
+	case GraphFormatHtml:
+		writeGraphHtml(w, graph)
+	default:
+		return fmt.Errorf("unsupported graph format '%s', expected one of [dot, mermaid, html]", format)
+	}
+
+	return nil
+}
+
+type graphBuilder struct {
+	refResolver
+	graph *AppGraph
+
+	resources   map[string]map[string]interface{}
+	actions     map[string]map[string]interface{}
+	flowNodes   map[string]string
+	actionNodes map[string]string
+}
+
+func (gb *graphBuilder) build(appObj map[string]interface{}) *AppGraph {
+
+	gb.graph = &AppGraph{}
+	gb.graph.Name, _ = appObj["name"].(string)
+	gb.resources = mapsById(appObj["resources"])
+	gb.actions = mapsById(appObj["actions"])
+	gb.flowNodes = make(map[string]string)
+	gb.actionNodes = make(map[string]string)
+
+	for _, trg := range toMaps(appObj["triggers"]) {
+		trgId, _ := trg["id"].(string)
+		ref, _ := trg["ref"].(string)
+		trgNode := gb.addNode(GraphNodeTrigger, labelLines(trgId, gb.contribName(ref, "trigger")))
+
+		for i, handler := range toMaps(trg["handlers"]) {
+			name, _ := handler["name"].(string)
+			if name == "" {
+				name = fmt.Sprintf("handler %d", i+1)
+			}
+			handlerNode := gb.addNode(GraphNodeHandler, labelLines(name, handlerSummary(handler)))
+			gb.addEdge(trgNode, handlerNode, false)
+
+			if action, ok := handler["action"].(map[string]interface{}); ok {
+				gb.addEdge(handlerNode, gb.addAction(action), false)
+			}
+			for _, action := range toMaps(handler["actions"]) {
+				gb.addEdge(handlerNode, gb.addAction(action), false)
+			}
+		}
+	}
+
+	// include actions and flows that aren't used by a trigger
+	for _, id := range sortedKeys(gb.actions) {
+		gb.addAction(gb.actions[id])
+	}
+	for _, id := range sortedKeys(gb.resources) {
+		if strings.HasPrefix(id, "flow:") {
+			gb.addFlow(id)
+		}
+	}
+
+	return gb.graph
+}
+
+func (gb *graphBuilder) addAction(action map[string]interface{}) string {
+
+	ref, _ := action["ref"].(string)
+	id, _ := action["id"].(string)
+
+	if id != "" {
+		if nodeId, exists := gb.actionNodes[id]; exists {
+			return nodeId
+		}
+		if shared, ok := gb.actions[id]; ok && ref == "" {
+			// handler action referring to an action in the actions section
+			action = shared
+			ref, _ = action["ref"].(string)
+		}
+	}
+
+	name := gb.contribName(ref, "action")
+	if name == "" {
+		name, _ = action["type"].(string)
+	}
+
+	nodeId := gb.addNode(GraphNodeAction, labelLines(id, name))
+	if id != "" {
+		gb.actionNodes[id] = nodeId
+	}
+
+	if settings, ok := action["settings"].(map[string]interface{}); ok {
+		if flowURI, ok := settings["flowURI"].(string); ok {
+			gb.addEdge(nodeId, gb.addFlow(strings.TrimPrefix(flowURI, resURIPrefix)), false)
+		}
+	}
+
+	return nodeId
+}
+
+func (gb *graphBuilder) addFlow(resId string) string {
+
+	if nodeId, exists := gb.flowNodes[resId]; exists {
+		return nodeId
+	}
+
+	res := gb.resources[resId]
+	data, _ := res["data"].(map[string]interface{})
+
+	name, _ := data["name"].(string)
+	if name == "" {
+		name = resId
+	}
+	label := name
+	if res == nil {
+		label = labelLines(name, "(missing)")
+	}
+
+	nodeId := gb.addNode(GraphNodeFlow, label)
+	gb.flowNodes[resId] = nodeId
+
+	gb.addTasks(nodeId, data)
+	if errorHandler, ok := data["errorHandler"].(map[string]interface{}); ok {
+		gb.addTasks(nodeId, errorHandler)
+	}
+
+	return nodeId
+}
+
+func (gb *graphBuilder) addTasks(flowNode string, data map[string]interface{}) {
+
+	taskNodes := make(map[string]string)
+
+	for _, task := range toMaps(data["tasks"]) {
+		taskId, _ := task["id"].(string)
+		name, _ := task["name"].(string)
+		if name == "" {
+			name = taskId
+		}
+
+		activity, _ := task["activity"].(map[string]interface{})
+		ref, _ := activity["ref"].(string)
+
+		taskNode := gb.addNode(GraphNodeActivity, labelLines(name, gb.contribName(ref, "activity")))
+		taskNodes[taskId] = taskNode
+		gb.addEdge(flowNode, taskNode, false)
+
+		// subflow
+		if settings, ok := activity["settings"].(map[string]interface{}); ok {
+			if flowURI, ok := settings["flowURI"].(string); ok {
+				gb.addEdge(taskNode, gb.addFlow(strings.TrimPrefix(flowURI, resURIPrefix)), false)
+			}
+		}
+	}
+
+	for _, link := range toMaps(data["links"]) {
+		from, _ := link["from"].(string)
+		to, _ := link["to"].(string)
+		if taskNodes[from] != "" && taskNodes[to] != "" {
+			gb.addEdge(taskNodes[from], taskNodes[to], true)
+		}
+	}
+}
+
+func (gb *graphBuilder) addNode(kind, label string) string {
+	node := &GraphNode{Id: fmt.Sprintf("n%d", len(gb.graph.Nodes)+1), Kind: kind, Label: label}
+	gb.graph.Nodes = append(gb.graph.Nodes, node)
+	return node.Id
+}
+
+func (gb *graphBuilder) addEdge(from, to string, link bool) {
+	gb.graph.Edges = append(gb.graph.Edges, &GraphEdge{From: from, To: to, Link: link})
+}
+
+func handlerSummary(handler map[string]interface{}) string {
+	settings, ok := handler["settings"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	for _, key := range []string{"method", "path", "topic", "queue", "schedule"} {
+		if val, ok := settings[key]; ok {
+			parts = append(parts, fmt.Sprintf("%v", val))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func labelLines(lines ...string) string {
+	var nonEmpty []string
+	for _, line := range lines {
+		if line != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+func toMaps(item interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	if items, ok := item.([]interface{}); ok {
+		for _, val := range items {
+			if m, ok := val.(map[string]interface{}); ok {
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
+
+func mapsById(item interface{}) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	for _, m := range toMaps(item) {
+		if id, ok := m["id"].(string); ok {
+			result[id] = m
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]map[string]interface{}) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeGraphDot(w io.Writer, graph *AppGraph) {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	fmt.Fprintf(w, "digraph \"%s\" {\n", escape.Replace(graph.Name))
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(w, "  %s [label=\"%s\", fillcolor=\"%s\"];\n", node.Id, escape.Replace(node.Label), graphColors[node.Kind])
+	}
+	for _, edge := range graph.Edges {
+		if edge.Link {
+			fmt.Fprintf(w, "  %s -> %s [style=dashed];\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(w, "  %s -> %s;\n", edge.From, edge.To)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeGraphMermaid(w io.Writer, graph *AppGraph) {
+	escape := strings.NewReplacer(`"`, "#quot;", "\n", "<br/>")
+
+	fmt.Fprintln(w, "flowchart LR")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", node.Id, escape.Replace(node.Label))
+	}
+	for _, edge := range graph.Edges {
+		if edge.Link {
+			fmt.Fprintf(w, "  %s -.-> %s\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(w, "  %s --> %s\n", edge.From, edge.To)
+		}
+	}
+	for _, kind := range graphKinds {
+		fmt.Fprintf(w, "  classDef %s fill:%s\n", kind, graphColors[kind])
+	}
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(w, "  class %s %s\n", node.Id, node.Kind)
+	}
+}
+
+const (
+	svgNodeWidth  = 180
+	svgNodeHeight = 48
+	svgColGap     = 60
+	svgRowGap     = 20
+	svgMargin     = 20
+)
+
+func writeGraphHtml(w io.Writer, graph *AppGraph) {
+
+	// simple layered layout, one column per node kind
+	type pos struct{ x, y int }
+	positions := make(map[string]pos)
+	rows := make(map[string]int)
+	maxRows := 0
+
+	for _, node := range graph.Nodes {
+		col := 0
+		for i, kind := range graphKinds {
+			if kind == node.Kind {
+				col = i
+			}
+		}
+		row := rows[node.Kind]
+		rows[node.Kind] = row + 1
+		if row+1 > maxRows {
+			maxRows = row + 1
+		}
+		positions[node.Id] = pos{x: svgMargin + col*(svgNodeWidth+svgColGap), y: svgMargin + row*(svgNodeHeight+svgRowGap)}
+	}
+
+	width := 2*svgMargin + len(graphKinds)*(svgNodeWidth+svgColGap)
+	height := 2*svgMargin + maxRows*(svgNodeHeight+svgRowGap)
+
+	title := html.EscapeString(graph.Name)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", title)
+	fmt.Fprintf(w, "<h1 style=\"font-family:Helvetica,sans-serif\">%s</h1>\n", title)
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"Helvetica,sans-serif\" font-size=\"12\">\n", width, height)
+	fmt.Fprintln(w, "<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\"><path d=\"M 0 0 L 10 5 L 0 10 z\"/></marker></defs>")
+
+	for _, edge := range graph.Edges {
+		from, to := positions[edge.From], positions[edge.To]
+		y1, y2 := from.y+svgNodeHeight/2, to.y+svgNodeHeight/2
+		if from.x == to.x {
+			// link between tasks in the same column, loop around the right side
+			x := from.x + svgNodeWidth
+			fmt.Fprintf(w, "<path d=\"M %d %d C %d %d, %d %d, %d %d\" fill=\"none\" stroke=\"#555\" stroke-dasharray=\"4 3\" marker-end=\"url(#arrow)\"/>\n",
+				x, y1, x+svgColGap/2, y1, x+svgColGap/2, y2, x, y2)
+			continue
+		}
+		x1, x2 := from.x+svgNodeWidth, to.x
+		mid := (x1 + x2) / 2
+		dash := ""
+		if edge.Link {
+			dash = " stroke-dasharray=\"4 3\""
+		}
+		fmt.Fprintf(w, "<path d=\"M %d %d C %d %d, %d %d, %d %d\" fill=\"none\" stroke=\"#555\"%s marker-end=\"url(#arrow)\"/>\n",
+			x1, y1, mid, y1, mid, y2, x2, y2, dash)
+	}
+
+	for _, node := range graph.Nodes {
+		p := positions[node.Id]
+		fmt.Fprintf(w, "<g><title>%s</title>\n", html.EscapeString(node.Kind))
+		fmt.Fprintf(w, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"6\" fill=\"%s\" stroke=\"#555\"/>\n",
+			p.x, p.y, svgNodeWidth, svgNodeHeight, graphColors[node.Kind])
+
+		lines := strings.Split(node.Label, "\n")
+		for i, line := range lines {
+			y := p.y + svgNodeHeight/2 + (2*i-len(lines)+1)*7 + 4
+			fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n", p.x+svgNodeWidth/2, y, html.EscapeString(line))
+		}
+		fmt.Fprintln(w, "</g>")
+	}
+
+	fmt.Fprintln(w, "</svg>\n</body>\n</html>")
+}
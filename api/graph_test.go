@@ -0,0 +1,42 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppGraph(t *testing.T) {
+	var appObj map[string]interface{}
+	err := json.Unmarshal([]byte(newJsonString), &appObj)
+	assert.Nil(t, err)
+
+	gb := &graphBuilder{}
+	graph := gb.build(appObj)
+
+	kinds := make(map[string]int)
+	for _, node := range graph.Nodes {
+		kinds[node.Kind]++
+	}
+
+	assert.Equal(t, 1, kinds[GraphNodeTrigger])
+	assert.Equal(t, 1, kinds[GraphNodeHandler])
+	assert.Equal(t, 1, kinds[GraphNodeAction])
+	assert.Equal(t, 1, kinds[GraphNodeFlow])
+	assert.Equal(t, 2, kinds[GraphNodeActivity])
+
+	// trigger->handler, handler->action, action->flow, flow->2 tasks, 1 task link
+	assert.Len(t, graph.Edges, 6)
+
+	for _, format := range []string{GraphFormatDot, GraphFormatMermaid, GraphFormatHtml} {
+		var buf bytes.Buffer
+		err = WriteAppGraph(&buf, graph, format)
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), "simple_flow")
+	}
+
+	err = WriteAppGraph(&bytes.Buffer{}, graph, "png")
+	assert.NotNil(t, err)
+}
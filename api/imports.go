@@ -1,8 +1,6 @@
 package api
 
 import (
-	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -20,12 +18,49 @@ func ListProjectImports(project common.AppProject) error {
 
 	for _, imp := range appImports.GetAllImports() {
 
-		fmt.Fprintf(os.Stdout, "  %s\n", imp)
+		printf(project, "  %s\n", imp)
 	}
 
+	conflicts, err := GetProjectImportConflicts(project)
+	if err != nil {
+		return err
+	}
+
+	printImportConflicts(project, conflicts)
+
 	return nil
 }
 
+// GetProjectImportConflicts returns the duplicate, version and alias conflicts between the project imports
+func GetProjectImportConflicts(project common.AppProject) ([]*util.ImportConflict, error) {
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return appImports.GetImportConflicts(), nil
+}
+
+func printImportConflicts(project common.AppProject, conflicts []*util.ImportConflict) {
+	for _, conflict := range conflicts {
+		eprintf(project, "Warning: %s\n", conflict)
+	}
+}
+
+// warnImportConflicts prints the project import conflicts, failing to determine them is not an error
+func warnImportConflicts(project common.AppProject) {
+	conflicts, err := GetProjectImportConflicts(project)
+	if err != nil {
+		if common.ProjectSession(project).Verbose() {
+			eprintf(project, "Unable to check imports for conflicts: %v\n", err)
+		}
+		return
+	}
+
+	printImportConflicts(project, conflicts)
+}
+
 func SyncProjectImports(project common.AppProject) error {
 
 	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), false)
@@ -50,19 +85,17 @@ func SyncProjectImports(project common.AppProject) error {
 	for goPath, imp := range appImportsMap {
 		if _, ok := goImportsMap[goPath]; !ok {
 			toAdd = append(toAdd, imp)
-			if Verbose() {
-				fmt.Println("Adding missing Go import: ", goPath)
-			}
+			logf(project, "Adding missing Go import: %s\n", goPath)
 		}
 	}
 
+	engImportsMap := make(map[string]util.Import)
 	if util.FileExists(filepath.Join(project.Dir(), fileEngineJson)) {
 		engineImports, err := util.GetEngineImports(filepath.Join(project.Dir(), fileEngineJson), project.DepManager())
 		if err != nil {
 			return err
 		}
 
-		engImportsMap := make(map[string]util.Import)
 		for _, imp := range engineImports.GetAllImports() {
 			engImportsMap[imp.GoImportPath()] = imp
 		}
@@ -70,23 +103,26 @@ func SyncProjectImports(project common.AppProject) error {
 		for goPath, imp := range engImportsMap {
 			if _, ok := goImportsMap[goPath]; !ok {
 				toAdd = append(toAdd, imp)
-				if Verbose() {
-					fmt.Println("Adding missing Go import: ", goPath)
-				}
+				logf(project, "Adding missing Go import: %s\n", goPath)
 			}
 		}
 	}
 
 	var toRemove []string
 	for goPath := range goImportsMap {
-		if _, ok := appImportsMap[goPath]; !ok {
+		_, inApp := appImportsMap[goPath]
+		_, inEngine := engImportsMap[goPath]
+		if !inApp && !inEngine {
 			toRemove = append(toRemove, goPath)
-			if Verbose() {
-				fmt.Println("Removing extraneous Go import: ", goPath)
-			}
+			logf(project, "Removing extraneous Go import: %s\n", goPath)
 		}
 	}
 
+	err = fireEvent(&common.SyncImportsEvent{Phase: common.PhasePre, Project: project, Added: toAdd, Removed: toRemove})
+	if err != nil {
+		return err
+	}
+
 	err = project.RemoveImports(toRemove...)
 	if err != nil {
 		return err
@@ -97,37 +133,29 @@ func SyncProjectImports(project common.AppProject) error {
 		return err
 	}
 
-	return nil
+	return fireEvent(&common.SyncImportsEvent{Phase: common.PhasePost, Project: project, Added: toAdd, Removed: toRemove})
 }
 
 func ResolveProjectImports(project common.AppProject) error {
-	if Verbose() {
-		fmt.Fprintln(os.Stdout, "Synchronizing project imports")
-	}
+	logf(project, "Synchronizing project imports\n")
 	err := SyncProjectImports(project)
 	if err != nil {
 		return err
 	}
 
-	if Verbose() {
-		fmt.Fprintln(os.Stdout, "Reading AIflow.json")
-	}
+	logf(project, "Reading AIflow.json\n")
 	appDescriptor, err := readAppDescriptor(project)
 	if err != nil {
 		return err
 	}
 
-	if Verbose() {
-		fmt.Fprintln(os.Stdout, "Updating AIflow.json import versions")
-	}
+	logf(project, "Updating AIflow.json import versions\n")
 	err = updateDescriptorImportVersions(project, appDescriptor)
 	if err != nil {
 		return err
 	}
 
-	if Verbose() {
-		fmt.Fprintln(os.Stdout, "Saving updated AIflow.json")
-	}
+	logf(project, "Saving updated AIflow.json\n")
 	err = writeAppDescriptor(project, appDescriptor)
 	if err != nil {
 		return err
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
+	"strings"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
 	"github.com/r2d2-ai/aiflow-cli/util"
@@ -17,15 +17,18 @@ func InstallPackage(project common.AppProject, pkg string) error {
 		return err
 	}
 
+	err = fireEvent(&common.InstallEvent{Phase: common.PhasePre, Project: project, Import: flowImport})
+	if err != nil {
+		return err
+	}
+
 	err = project.AddImports(false, true, flowImport)
 	if err != nil {
 		return err
 	}
 
 	path, err := project.GetPath(flowImport)
-	if Verbose() {
-		fmt.Println("Installed path", path)
-	}
+	logf(project, "Installed path %s\n", path)
 	if err != nil {
 		return err
 	}
@@ -37,13 +40,13 @@ func InstallPackage(project common.AppProject, pkg string) error {
 		if desc.IsLegacy {
 			legacySupportRequired = true
 			cType = "legacy " + desc.GetContribType()
-			err := CreateLegacyMetadata(path, desc.GetContribType(), pkg)
+			err := createLegacyMetadata(common.ProjectSession(project), path, desc.GetContribType(), pkg)
 			if err != nil {
 				return err
 			}
 		}
 
-		fmt.Printf("Installed %s: %s\n", cType, flowImport)
+		printf(project, "Installed %s: %s\n", cType, flowImport)
 		//instStr := fmt.Sprintf("Installed %s:", cType)
 		//fmt.Printf("%-20s %s\n", instStr, imp)
 	}
@@ -55,7 +58,50 @@ func InstallPackage(project common.AppProject, pkg string) error {
 		}
 	}
 
-	return nil
+	warnImportConflicts(project)
+
+	return fireEvent(&common.InstallEvent{Phase: common.PhasePost, Project: project, Import: flowImport, Descriptor: desc})
+}
+
+// UninstallPackage removes a contribution or dependency from the app imports and the Go imports
+func UninstallPackage(project common.AppProject, pkg string) error {
+
+	flowImport, err := util.ParseImport(pkg)
+	if err != nil {
+		return err
+	}
+
+	err = fireEvent(&common.UninstallEvent{Phase: common.PhasePre, Project: project, Import: flowImport})
+	if err != nil {
+		return err
+	}
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return err
+	}
+
+	if refs := importAliasRefs(project, appObj, flowImport); len(refs) > 0 {
+		return fmt.Errorf("import '%s' is still used by %s, remove them or replace them with other refs first", flowImport.GoImportPath(), strings.Join(refs, ", "))
+	}
+
+	if !removeImportFromMap(appObj, flowImport) {
+		return fmt.Errorf("import '%s' not found in %s", flowImport.GoImportPath(), fileAIflowJson)
+	}
+
+	err = writeAppDescriptorMap(project, appObj)
+	if err != nil {
+		return err
+	}
+
+	err = project.RemoveImports(flowImport.GoImportPath())
+	if err != nil {
+		return err
+	}
+
+	printf(project, "Uninstalled: %s\n", flowImport.GoImportPath())
+
+	return fireEvent(&common.UninstallEvent{Phase: common.PhasePost, Project: project, Import: flowImport})
 }
 
 func InstallReplacedPackage(project common.AppProject, replacedPath string, pkg string) error {
@@ -84,9 +130,63 @@ func InstallContribBundle(project common.AppProject, path string) error {
 	for _, contrib := range contribBundleDescriptor.Contribs {
 		err := InstallPackage(project, contrib)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error installing contrib '%s': %s", contrib, err.Error())
+			eprintf(project, "Error installing contrib '%s': %s\n", contrib, err.Error())
 		}
 	}
 
 	return nil
 }
+
+// importAliasRefs returns the '#alias' refs of the app that use the import, with their number of occurrences. When
+// the alias is also used by imports of other contribution types only the refs of the type of the import are returned,
+// or none if its type can't be determined.
+func importAliasRefs(project common.AppProject, appObj map[string]interface{}, imp util.Import) []string {
+
+	var alias string
+	shared := false
+
+	imports, _ := appObj["imports"].([]interface{})
+	for _, val := range imports {
+		if existing, err := util.ParseImport(fmt.Sprint(val)); err == nil && existing.GoImportPath() == imp.GoImportPath() {
+			alias = existing.CanonicalAlias()
+		}
+	}
+	if alias == "" {
+		return nil
+	}
+	for _, val := range imports {
+		if other, err := util.ParseImport(fmt.Sprint(val)); err == nil && other.GoImportPath() != imp.GoImportPath() && other.CanonicalAlias() == alias {
+			shared = true
+		}
+	}
+
+	contribType := ""
+	if shared {
+		desc, err := util.GetContribDescriptorFromImport(project.DepManager(), imp)
+		if err != nil || desc == nil || desc.GetContribType() == "" {
+			return nil
+		}
+		contribType = desc.GetContribType()
+	}
+
+	var refs []string
+	counts := make(map[string]int)
+	util.RewriteAppRefs(appObj, func(ref string, ct string) (string, bool) {
+		if strings.TrimSpace(ref) == "#"+alias && (contribType == "" || ct == contribType) {
+			key := fmt.Sprintf("%s '#%s'", ct, alias)
+			if counts[key] == 0 {
+				refs = append(refs, key)
+			}
+			counts[key]++
+		}
+		return ref, false
+	})
+
+	for i, ref := range refs {
+		if counts[ref] > 1 {
+			refs[i] = fmt.Sprintf("%s (%d refs)", ref, counts[ref])
+		}
+	}
+
+	return refs
+}
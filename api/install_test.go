@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -121,7 +123,7 @@ func TestInstallLegacyPkg(t *testing.T) {
 
 	common.SetCurrentProject(appProject)
 
-	err = BuildProject(common.CurrentProject(), common.BuildOptions{})
+	_, err = BuildProject(common.CurrentProject(), common.BuildOptions{})
 	assert.Nil(t, err)
 
 }
@@ -152,7 +154,7 @@ func TestInstallPkg(t *testing.T) {
 
 	common.SetCurrentProject(appProject)
 
-	err = BuildProject(common.CurrentProject(), common.BuildOptions{})
+	_, err = BuildProject(common.CurrentProject(), common.BuildOptions{})
 	assert.Nil(t, err)
 }
 
@@ -182,7 +184,7 @@ func TestInstallPkgWithVersion(t *testing.T) {
 
 	common.SetCurrentProject(appProject)
 
-	err = BuildProject(common.CurrentProject(), common.BuildOptions{})
+	_, err = BuildProject(common.CurrentProject(), common.BuildOptions{})
 	assert.Nil(t, err)
 }
 
@@ -206,3 +208,86 @@ func TestListPkg(t *testing.T) {
 	assert.Equal(t, nil, err)
 
 }
+
+var uninstallAppJson = `{
+  "name": "temp",
+  "type": "AIflow:app",
+  "imports": [
+    "github.com/r2d2-ai/aiflow/action/flow",
+    "github.com/r2d2-ai/aiflow/trigger/net/rest",
+    "github.com/r2d2-ai/aiflow/activity/common/log",
+    "github.com/r2d2-ai/aiflow/activity/common/noop"
+  ],
+  "triggers": [
+    {
+      "id": "my_rest_trigger",
+      "ref": "#rest",
+      "handlers": [
+        { "action": { "ref": "#flow", "settings": { "flowURI": "res://flow:simple_flow" } } }
+      ]
+    }
+  ],
+  "resources": [
+    {
+      "id": "flow:simple_flow",
+      "data": {
+        "tasks": [
+          { "id": "log", "activity": { "ref": "#log" } },
+          { "id": "log2", "activity": { "ref": "#log" } }
+        ]
+      }
+    }
+  ]
+}
+`
+
+func TestUninstallPackageRefs(t *testing.T) {
+
+	tempDir := newWorkspaceTestDir(t, "app")
+	defer os.RemoveAll(tempDir)
+
+	appDir := filepath.Join(tempDir, "app")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, fileAIflowJson), []byte(uninstallAppJson), 0644))
+	importsGo := "package main\n\nimport (\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/noop\"\n)\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, dirSrc, fileImportsGo), []byte(importsGo), 0644))
+
+	project := NewAppProject(appDir)
+
+	err := UninstallPackage(project, "github.com/r2d2-ai/aiflow/activity/common/log")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "activity '#log' (2 refs)")
+
+	// a pre hook returning an error stops the uninstall
+	veto := true
+	registered := common.Hooks()
+	defer common.SetHooks(registered)
+
+	var phases []common.HookPhase
+	common.RegisterHook(common.HookFunc(func(event common.Event) error {
+		if _, ok := event.(*common.UninstallEvent); !ok {
+			return nil
+		}
+		phases = append(phases, event.EventPhase())
+		if veto && event.EventPhase() == common.PhasePre {
+			return errors.New("vetoed")
+		}
+		return nil
+	}))
+	defer func() { veto = false }()
+
+	err = UninstallPackage(project, "github.com/r2d2-ai/aiflow/activity/common/noop")
+	assert.EqualError(t, err, "uninstall pre hook failed: vetoed")
+
+	buf, err := ioutil.ReadFile(filepath.Join(appDir, fileAIflowJson))
+	assert.Nil(t, err)
+	assert.Equal(t, uninstallAppJson, string(buf))
+
+	veto = false
+	assert.Nil(t, UninstallPackage(project, "github.com/r2d2-ai/aiflow/activity/common/noop"))
+	assert.Equal(t, []common.HookPhase{common.PhasePre, common.PhasePre, common.PhasePost}, phases)
+
+	buf, err = ioutil.ReadFile(filepath.Join(appDir, fileAIflowJson))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(buf), "activity/common/noop")
+	assert.Contains(t, string(buf), "activity/common/log")
+}
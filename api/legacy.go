@@ -2,7 +2,6 @@ package api
 
 //Legacy Helper Functions
 import (
-	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -26,12 +25,16 @@ func InstallLegacySupport(project common.AppProject) error {
 	}
 	err = project.AddImports(false, true, pkgLegacySupportImport)
 	if err == nil {
-		fmt.Println("Installed Legacy Support")
+		printf(project, "Installed Legacy Support\n")
 	}
 	return err
 }
 
 func CreateLegacyMetadata(path, contribType, contribPkg string) error {
+	return createLegacyMetadata(common.DefaultSession(), path, contribType, contribPkg)
+}
+
+func createLegacyMetadata(session *common.Session, path, contribType, contribPkg string) error {
 
 	var mdGoFilePath string
 
@@ -42,11 +45,11 @@ func CreateLegacyMetadata(path, contribType, contribPkg string) error {
 		//ignore
 		return nil
 	case "trigger":
-		fmt.Printf("Generating metadata for legacy trigger: %s\n", contribPkg)
+		session.Printf("Generating metadata for legacy trigger: %s\n", contribPkg)
 		mdGoFilePath = filepath.Join(path, "trigger_metadata.go")
 		tplMetadata = tplTriggerMetadataGoFile
 	case "activity":
-		fmt.Printf("Generating metadata for legacy actvity: %s\n", contribPkg)
+		session.Printf("Generating metadata for legacy actvity: %s\n", contribPkg)
 		mdGoFilePath = filepath.Join(path, "activity_metadata.go")
 		tplMetadata = tplActivityMetadataGoFile
 	default:
@@ -2,8 +2,6 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -15,62 +13,77 @@ type ListFilter int
 
 func ListContribs(project common.AppProject, jsonFormat bool, filter string) error {
 
-	ai, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	specs, err := GetContribSpecs(project, filter)
 	if err != nil {
 		return err
 	}
 
-	var specs []*ContribSpec
+	if len(specs) == 0 {
+		return nil
+	}
 
-	for _, details := range ai.GetAllImportDetails() {
+	if jsonFormat {
+		resp, err := json.MarshalIndent(specs, "", "  ")
+		if err != nil {
+			return err
+		}
 
-		if !includeContrib(details, filter) {
-			continue
+		printf(project, "%v \n", string(resp))
+	} else {
+		for _, spec := range specs {
+			printf(project, "Contrib: %s\n", spec.Name)
+			printf(project, "  Type       : %s\n", spec.Type)
+			if spec.IsLegacy != nil {
+				printf(project, "  IsLegacy   : true\n")
+			}
+			printf(project, "  Homepage   : %s\n", spec.Homepage)
+			printf(project, "  Ref        : %s\n", spec.Ref)
+			printf(project, "  Path       : %s\n", spec.Path)
+			printf(project, "  Descriptor : %s\n", spec.Path)
+			printf(project, "  Description: %s\n", spec.Description)
+			printf(project, "\n")
 		}
+	}
 
-		specs = append(specs, getContribSpec(project, details))
+	return nil
+}
 
+// GetContribSpecs returns the specs of the contributions of the project accepted by the filter, 'used' or 'unused',
+// and of the functions
+func GetContribSpecs(project common.AppProject, filter string) ([]*ContribSpec, error) {
+
+	ai, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return nil, err
 	}
 
+	var specs []*ContribSpec
+
 	for _, details := range ai.GetAllImportDetails() {
 
-		if details.ContribDesc == nil {
+		if !includeContrib(details, filter) {
 			continue
 		}
 
-		if details.ContribDesc.Type == "AIflow:function" {
-			specs = append(specs, getContribSpec(project, details))
+		if spec := getContribSpec(project, details); spec != nil {
+			specs = append(specs, spec)
 		}
 	}
 
-	if len(specs) == 0 {
-		return nil
-	}
+	for _, details := range ai.GetAllImportDetails() {
 
-	if jsonFormat {
-		resp, err := json.MarshalIndent(specs, "", "  ")
-		if err != nil {
-			return err
+		if details.ContribDesc == nil {
+			continue
 		}
 
-		fmt.Fprintf(os.Stdout, "%v \n", string(resp))
-	} else {
-		for _, spec := range specs {
-			fmt.Println("Contrib: " + spec.Name)
-			fmt.Println("  Type       : " + spec.Type)
-			if spec.IsLegacy != nil {
-				fmt.Println("  IsLegacy   : true")
+		if details.ContribDesc.Type == "AIflow:function" {
+			if spec := getContribSpec(project, details); spec != nil {
+				specs = append(specs, spec)
 			}
-			fmt.Println("  Homepage   : " + spec.Homepage)
-			fmt.Println("  Ref        : " + spec.Ref)
-			fmt.Println("  Path       : " + spec.Path)
-			fmt.Println("  Descriptor : " + spec.Path)
-			fmt.Println("  Description: " + spec.Description)
-			fmt.Println()
 		}
 	}
 
-	return nil
+	return specs, nil
 }
 
 func includeContrib(details *util.AppImportDetails, filter string) bool {
@@ -108,9 +121,7 @@ func getContribSpec(project common.AppProject, details *util.AppImportDetails) *
 		return nil
 	}
 
-	if Verbose() {
-		fmt.Println("Path of contrib", path, "for contrib", details.Imp)
-	}
+	logf(project, "Path of contrib %s for contrib %v\n", path, details.Imp)
 
 	desc := details.ContribDesc
 
@@ -146,10 +157,10 @@ func ListOrphanedRefs(project common.AppProject, jsonFormat bool) error {
 			return err
 		}
 
-		fmt.Fprintf(os.Stdout, "%v \n", string(resp))
+		printf(project, "%v \n", string(resp))
 	} else {
 		for _, ref := range orphaned {
-			fmt.Println(ref)
+			printf(project, "%s\n", ref)
 		}
 	}
 
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// LegacyMapping maps legacy contributions to equivalent modern contributions
+type LegacyMapping struct {
+	Contributions []*LegacyContribMapping `json:"contributions"`
+}
+
+// LegacyContribMapping is the replacement of a legacy contribution, Attributes renames the settings,
+// inputs and outputs whose name changed in the replacement
+type LegacyContribMapping struct {
+	Legacy     string            `json:"legacy"`
+	Ref        string            `json:"ref"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// LoadLegacyMapping loads a mapping file
+func LoadLegacyMapping(path string) (*LegacyMapping, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := &LegacyMapping{}
+	err = json.Unmarshal(data, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file '%s': %v", path, err)
+	}
+
+	for _, contrib := range mapping.Contributions {
+		if contrib.Legacy == "" || contrib.Ref == "" {
+			return nil, fmt.Errorf("invalid mapping file '%s': 'legacy' and 'ref' are required", path)
+		}
+	}
+
+	return mapping, nil
+}
+
+func (m *LegacyMapping) find(goImportPath string) *LegacyContribMapping {
+	for _, contrib := range m.Contributions {
+		if imp, err := util.ParseImport(contrib.Legacy); err == nil && imp.GoImportPath() == goImportPath {
+			return contrib
+		}
+	}
+	return nil
+}
+
+// LegacyContribMigration is the replacement of a legacy contribution and the number of refs updated
+type LegacyContribMigration struct {
+	Type        string
+	Legacy      string
+	Replacement string
+	Refs        int
+}
+
+// LegacyMigrationReport lists the migrated contributions and what could not be migrated
+type LegacyMigrationReport struct {
+	Migrated      []*LegacyContribMigration
+	NotMigrated   []string
+	BridgeRemoved bool
+}
+
+type legacyReplacement struct {
+	legacy      util.Import
+	replacement util.Import
+	contribType string
+	attributes  map[string]string
+	refs        int
+}
+
+// MigrateLegacyApp replaces the legacy contributions of the app with the modern contributions of the mapping
+// file, rewriting their refs and configs in AIflow.json, and removes the legacy bridge when no longer needed.
+// Without a mapping file the legacy contributions are only reported, with dryRun nothing is changed.
+func MigrateLegacyApp(project common.AppProject, mappingFile string, dryRun bool) (*LegacyMigrationReport, error) {
+
+	mapping := &LegacyMapping{}
+	if mappingFile != "" {
+		var err error
+		mapping, err = LoadLegacyMapping(mappingFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LegacyMigrationReport{}
+	legacyRemaining := false
+	usedAliases := make(map[string]bool)
+
+	var replacements []*legacyReplacement
+	for _, details := range appImports.GetAllImportDetails() {
+		goPath := details.Imp.GoImportPath()
+		if !details.TopLevel || goPath == pkgLegacySupport {
+			continue
+		}
+
+		contribMapping := mapping.find(goPath)
+		if contribMapping == nil {
+			usedAliases[details.Imp.CanonicalAlias()] = true
+			if details.ContribDesc != nil && details.ContribDesc.IsLegacy {
+				legacyRemaining = true
+				report.NotMigrated = append(report.NotMigrated, fmt.Sprintf("legacy %s %s: no replacement in the mapping file", importContribType(details), goPath))
+			}
+			continue
+		}
+
+		replacement, err := util.ParseImport(contribMapping.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replacement for '%s': %v", goPath, err)
+		}
+
+		replacements = append(replacements, &legacyReplacement{legacy: details.Imp, replacement: replacement,
+			contribType: importContribType(details), attributes: contribMapping.Attributes})
+	}
+
+	sort.Strings(report.NotMigrated)
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].legacy.GoImportPath() < replacements[j].legacy.GoImportPath()
+	})
+
+	// the replacement keeps the legacy alias if its own alias is already used
+	for _, r := range replacements {
+		if usedAliases[r.replacement.CanonicalAlias()] {
+			r.replacement = util.NewAIflowImport(r.replacement.ModulePath(), r.replacement.RelativeImportPath(), r.replacement.Version(), r.legacy.CanonicalAlias())
+		}
+		usedAliases[r.replacement.CanonicalAlias()] = true
+	}
+
+	report.NotMigrated = append(report.NotMigrated, migrateAppObject(appObj, replacements)...)
+
+	for _, r := range replacements {
+		replaceImportInMap(appObj, r.legacy, r.replacement)
+		report.Migrated = append(report.Migrated, &LegacyContribMigration{Type: r.contribType, Legacy: r.legacy.GoImportPath(),
+			Replacement: r.replacement.GoImportPath(), Refs: r.refs})
+	}
+
+	bridgeImport := util.NewAIflowImport(pkgLegacySupport, "", "", "")
+	if !legacyRemaining {
+		report.BridgeRemoved = removeImportFromMap(appObj, bridgeImport)
+	}
+
+	printLegacyMigrationReport(project, report, dryRun)
+
+	if dryRun || (len(report.Migrated) == 0 && !report.BridgeRemoved) {
+		return report, nil
+	}
+
+	err = writeAppDescriptorMap(project, appObj)
+	if err != nil {
+		return report, err
+	}
+
+	var removed []string
+	var added []util.Import
+	for _, r := range replacements {
+		removed = append(removed, r.legacy.GoImportPath())
+		added = append(added, r.replacement)
+	}
+	if report.BridgeRemoved {
+		removed = append(removed, pkgLegacySupport)
+	}
+
+	err = project.RemoveImports(removed...)
+	if err != nil {
+		return report, err
+	}
+
+	return report, project.AddImports(false, false, added...)
+}
+
+func printLegacyMigrationReport(project common.AppProject, report *LegacyMigrationReport, dryRun bool) {
+
+	migrated, removed := "Migrated", "Removed"
+	if dryRun {
+		migrated, removed = "Would migrate", "Would remove"
+	}
+
+	for _, m := range report.Migrated {
+		printf(project, "%s %s %s to %s (%d refs)\n", migrated, m.Type, m.Legacy, m.Replacement, m.Refs)
+	}
+
+	if report.BridgeRemoved {
+		printf(project, "%s the legacy bridge import %s\n", removed, pkgLegacySupport)
+	}
+
+	if len(report.NotMigrated) > 0 {
+		printf(project, "Not migrated:\n")
+		for _, problem := range report.NotMigrated {
+			printf(project, "  %s\n", problem)
+		}
+	}
+
+	if len(report.Migrated) == 0 && len(report.NotMigrated) == 0 && !report.BridgeRemoved {
+		printf(project, "No legacy contributions found\n")
+	}
+}
+
+// migrateAppObject rewrites the refs and configs of the replaced contributions, the problems that must be
+// fixed manually are returned
+func migrateAppObject(appObj map[string]interface{}, replacements []*legacyReplacement) []string {
+
+	var problems []string
+
+	util.WalkAppContribConfigs(appObj, func(config map[string]interface{}, contribType string) {
+		ref := strings.TrimSpace(config["ref"].(string))
+		for _, r := range replacements {
+			if !r.matches(ref, contribType) {
+				continue
+			}
+
+			config["ref"] = r.newRef(ref)
+			r.refs++
+			problems = append(problems, r.migrateConfig(config, contribType)...)
+			return
+		}
+	})
+
+	return problems
+}
+
+func (r *legacyReplacement) matches(ref, contribType string) bool {
+	if ref == "" {
+		return false
+	}
+
+	if ref[0] == '#' {
+		return ref[1:] == r.legacy.CanonicalAlias() && (r.contribType == "" || r.contribType == contribType)
+	}
+
+	refImport, err := util.ParseImport(ref)
+	return err == nil && refImport.GoImportPath() == r.legacy.GoImportPath()
+}
+
+func (r *legacyReplacement) newRef(ref string) string {
+	if ref[0] == '#' {
+		return "#" + r.replacement.CanonicalAlias()
+	}
+	return r.replacement.GoImportPath()
+}
+
+func (r *legacyReplacement) migrateConfig(config map[string]interface{}, contribType string) []string {
+
+	var problems []string
+
+	// the legacy mappings are converted first so that the inputs they map to are renamed
+	if mappings, ok := config["mappings"].(map[string]interface{}); ok {
+		problems = convertLegacyMappings(config, mappings, r.legacy.GoImportPath())
+	}
+
+	for _, section := range []string{"settings", "input", "output"} {
+		renameAttributes(config[section], r.attributes)
+	}
+
+	if contribType == "trigger" {
+		if handlers, ok := config["handlers"].([]interface{}); ok {
+			for _, handler := range handlers {
+				if handlerMap, ok := handler.(map[string]interface{}); ok {
+					renameAttributes(handlerMap["settings"], r.attributes)
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// convertLegacyMappings converts the legacy input and output mappings, lists of {type, value, mapTo},
+// to the input and output maps
+func convertLegacyMappings(config map[string]interface{}, mappings map[string]interface{}, contrib string) []string {
+
+	var problems []string
+
+	for _, section := range []string{"input", "output"} {
+		sectionMappings, ok := mappings[section].([]interface{})
+		if !ok {
+			continue
+		}
+
+		values, ok := config[section].(map[string]interface{})
+		if !ok {
+			values = make(map[string]interface{})
+		}
+
+		var remaining []interface{}
+		for _, mapping := range sectionMappings {
+			mappingObj, _ := mapping.(map[string]interface{})
+			mapTo, _ := mappingObj["mapTo"].(string)
+			if mapTo == "" {
+				problems = append(problems, fmt.Sprintf("%s: invalid legacy %s mapping %v", contrib, section, mapping))
+				remaining = append(remaining, mapping)
+				continue
+			}
+
+			val, ok := legacyMappingValue(mappingObj["type"], mappingObj["value"])
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unsupported legacy mapping type '%v' for %s '%s'", contrib, mappingObj["type"], section, mapTo))
+				remaining = append(remaining, mapping)
+				continue
+			}
+
+			values[mapTo] = val
+		}
+
+		if len(values) > 0 {
+			config[section] = values
+		}
+
+		if len(remaining) > 0 {
+			mappings[section] = remaining
+		} else {
+			delete(mappings, section)
+		}
+	}
+
+	if len(mappings) == 0 {
+		delete(config, "mappings")
+	}
+
+	return problems
+}
+
+func legacyMappingValue(mappingType, value interface{}) (interface{}, bool) {
+	switch fmt.Sprintf("%v", mappingType) {
+	case "1", "assign", "3", "expression":
+		strVal, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		return "=" + strVal, true
+	case "2", "literal":
+		return value, true
+	case "4", "object":
+		return map[string]interface{}{"mapping": value}, true
+	default:
+		return nil, false
+	}
+}
+
+func renameAttributes(section interface{}, attributes map[string]string) {
+
+	attrs, ok := section.(map[string]interface{})
+	if !ok || len(attributes) == 0 {
+		return
+	}
+
+	renamed := make(map[string]interface{})
+	for oldName, newName := range attributes {
+		if val, ok := attrs[oldName]; ok && oldName != newName {
+			renamed[newName] = val
+			delete(attrs, oldName)
+		}
+	}
+
+	for name, val := range renamed {
+		attrs[name] = val
+	}
+}
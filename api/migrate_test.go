@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var legacyAppJson = `{
+  "imports": [
+    "github.com/TIBCOSoftware/flogo-contrib/activity/log",
+    "github.com/r2d2-ai/aiflow/action/flow"
+  ],
+  "resources": [
+    {
+      "id": "flow:simple_flow",
+      "data": {
+        "tasks": [
+          {
+            "id": "log",
+            "activity": {
+              "ref": "#log",
+              "mappings": {
+                "input": [
+                  { "type": 1, "value": "$flow.message", "mapTo": "message" },
+                  { "type": "literal", "value": true, "mapTo": "flowInfo" },
+                  { "type": 9, "value": "x", "mapTo": "other" }
+                ]
+              }
+            }
+          },
+          {
+            "id": "log2",
+            "activity": { "ref": "github.com/TIBCOSoftware/flogo-contrib/activity/log", "input": { "flowInfo": false } }
+          }
+        ]
+      }
+    }
+  ]
+}`
+
+func TestMigrateAppObject(t *testing.T) {
+
+	var appObj map[string]interface{}
+	err := json.Unmarshal([]byte(legacyAppJson), &appObj)
+	assert.Nil(t, err)
+
+	legacy, _ := util.ParseImport("github.com/TIBCOSoftware/flogo-contrib/activity/log")
+	replacement, _ := util.ParseImport("github.com/r2d2-ai/aiflow/activity/common/log")
+	r := &legacyReplacement{legacy: legacy, replacement: replacement, contribType: "activity", attributes: map[string]string{"flowInfo": "addDetails"}}
+
+	problems := migrateAppObject(appObj, []*legacyReplacement{r})
+	assert.Equal(t, 2, r.refs)
+	assert.Len(t, problems, 1)
+
+	tasks := appObj["resources"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})["tasks"].([]interface{})
+
+	activity := tasks[0].(map[string]interface{})["activity"].(map[string]interface{})
+	assert.Equal(t, "#log", activity["ref"])
+	assert.Equal(t, map[string]interface{}{"message": "=$flow.message", "addDetails": true}, activity["input"])
+	assert.Len(t, activity["mappings"].(map[string]interface{})["input"], 1)
+
+	activity = tasks[1].(map[string]interface{})["activity"].(map[string]interface{})
+	assert.Equal(t, "github.com/r2d2-ai/aiflow/activity/common/log", activity["ref"])
+	assert.Equal(t, map[string]interface{}{"addDetails": false}, activity["input"])
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// OutdatedModule is a module providing contributions of the app for which a newer version is available
+type OutdatedModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Latest  string `json:"latest"`
+}
+
+// goListModule is the subset of the output of 'go list -m -json' used to find the outdated modules
+type goListModule struct {
+	Path    string
+	Version string
+	Update  *struct {
+		Version string
+	}
+	Replace *struct {
+		Path string
+	}
+}
+
+// ListOutdatedModules returns the modules of go.mod providing the contributions of the app that have a newer version,
+// the modules replaced by go.mod aren't reported
+func ListOutdatedModules(project common.AppProject) ([]*OutdatedModule, error) {
+
+	buf, err := ioutil.ReadFile(filepath.Join(project.Dir(), fileAIflowJson))
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := util.ParseAppDescriptor(string(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	imports, err := contribImports(project, descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	goModImports, err := project.DepManager().GetAllImports()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	var modules []string
+	for _, imp := range imports {
+		if module := providingModule(goModImports, imp.GoImportPath()); module != "" && !found[module] {
+			found[module] = true
+			modules = append(modules, module)
+		}
+	}
+
+	if len(modules) == 0 {
+		return nil, nil
+	}
+	sort.Strings(modules)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-m", "-u", "-json"}, modules...)...)
+	cmd.Dir = project.SrcDir()
+	cmd.Env = common.ProjectSession(project).Environ()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("unable to list the module updates: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var outdated []*OutdatedModule
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var module goListModule
+		if err := decoder.Decode(&module); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if module.Update != nil && module.Replace == nil {
+			outdated = append(outdated, &OutdatedModule{Path: module.Path, Version: module.Version, Latest: module.Update.Version})
+		}
+	}
+
+	return outdated, nil
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// the overlays of an environment are AIflow.<env>.json, a JSON merge patch of AIflow.json, AIflow.<env>.properties,
+// values of the app properties, and engine.<env>.json, a JSON merge patch of engine.json
+func appOverlayFile(env string) string {
+	return "AIflow." + env + ".json"
+}
+
+func propertiesOverlayFile(env string) string {
+	return "AIflow." + env + ".properties"
+}
+
+func engineOverlayFile(env string) string {
+	return "engine." + env + ".json"
+}
+
+// ListEnvs returns the environments that have overlays in the project
+func ListEnvs(project common.AppProject) []string {
+
+	found := make(map[string]bool)
+	for _, pattern := range []string{appOverlayFile("*"), propertiesOverlayFile("*"), engineOverlayFile("*")} {
+		matches, _ := filepath.Glob(filepath.Join(project.Dir(), pattern))
+		for _, match := range matches {
+			name := filepath.Base(match)
+			env := strings.TrimSuffix(name[strings.Index(name, ".")+1:], filepath.Ext(name))
+			if env != "" {
+				found[env] = true
+			}
+		}
+	}
+
+	var envs []string
+	for env := range found {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	return envs
+}
+
+// RenderAppDescriptor returns AIflow.json with the overlays of the environment applied, AIflow.json is returned
+// unchanged if no environment is specified or the environment only has an engine.json overlay
+func RenderAppDescriptor(project common.AppProject, env string) (string, error) {
+
+	buf, err := ioutil.ReadFile(filepath.Join(project.Dir(), fileAIflowJson))
+	if err != nil {
+		return "", err
+	}
+
+	if env == "" {
+		return string(buf), nil
+	}
+
+	appOverlay := filepath.Join(project.Dir(), appOverlayFile(env))
+	propertiesOverlay := filepath.Join(project.Dir(), propertiesOverlayFile(env))
+
+	if !util.FileExists(appOverlay) && !util.FileExists(propertiesOverlay) {
+		if !util.FileExists(filepath.Join(project.Dir(), engineOverlayFile(env))) {
+			return "", fmt.Errorf("no overlay found for environment '%s', expected %s, %s or %s", env,
+				appOverlayFile(env), propertiesOverlayFile(env), engineOverlayFile(env))
+		}
+		return string(buf), nil
+	}
+
+	var appObj map[string]interface{}
+	err = json.Unmarshal(buf, &appObj)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %v", fileAIflowJson, err)
+	}
+
+	rendered := interface{}(appObj)
+	if util.FileExists(appOverlay) {
+		rendered, err = applyMergePatchFile(rendered, appOverlay)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	appObj, ok := rendered.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("%s doesn't render an app descriptor object", appOverlayFile(env))
+	}
+
+	if util.FileExists(propertiesOverlay) {
+		properties, err := readPropertiesFile(propertiesOverlay)
+		if err != nil {
+			return "", err
+		}
+
+		err = applyAppProperties(appObj, properties)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", propertiesOverlayFile(env), err)
+		}
+	}
+
+	out, err := json.MarshalIndent(appObj, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// RenderEngineDescriptor returns engine.json with the overlay of the environment applied, an empty descriptor
+// is returned if the project has no engine.json
+func RenderEngineDescriptor(project common.AppProject, env string) (string, error) {
+
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	if !util.FileExists(engineJsonPath) {
+		return "", nil
+	}
+
+	buf, err := ioutil.ReadFile(engineJsonPath)
+	if err != nil {
+		return "", err
+	}
+
+	engineOverlay := filepath.Join(project.Dir(), engineOverlayFile(env))
+	if env == "" || !util.FileExists(engineOverlay) {
+		return string(buf), nil
+	}
+
+	var engineObj interface{}
+	err = json.Unmarshal(buf, &engineObj)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %v", fileEngineJson, err)
+	}
+
+	rendered, err := applyMergePatchFile(engineObj, engineOverlay)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.MarshalIndent(rendered, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// ValidateProject validates the project and its descriptor with the overlays of the environment applied
+func ValidateProject(project common.AppProject, env string) error {
+
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	appJson, err := RenderAppDescriptor(project, env)
+	if err != nil {
+		return err
+	}
+
+	return validateAppDescriptor(project, appJson)
+}
+
+// validateAppDescriptor checks that a rendered descriptor is a valid app descriptor
+func validateAppDescriptor(project common.AppProject, appJson string) error {
+
+	descriptor, err := util.ParseAppDescriptor(appJson)
+	if err != nil {
+		return fmt.Errorf("invalid app descriptor: %v", err)
+	}
+
+	if _, err := util.ParseImports(descriptor.Imports); err != nil {
+		return fmt.Errorf("invalid app descriptor: %v", err)
+	}
+
+	if len(common.Hooks()) > 0 {
+		return fireEvent(&common.ValidateEvent{Project: project, Descriptor: descriptor})
+	}
+
+	return nil
+}
+
+func applyMergePatchFile(target interface{}, patchFile string) (interface{}, error) {
+
+	buf, err := ioutil.ReadFile(patchFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch interface{}
+	err = json.Unmarshal(buf, &patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overlay '%s': %v", filepath.Base(patchFile), err)
+	}
+
+	return mergePatch(target, patch), nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386): objects are merged recursively, null removes a member
+// and any other value replaces the target
+func mergePatch(target, patch interface{}) interface{} {
+
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, val := range patchObj {
+		if val == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], val)
+		}
+	}
+
+	return targetObj
+}
+
+// readPropertiesFile reads a properties file of name=value or name: value lines, lines starting with # or ! are comments
+func readPropertiesFile(path string) (map[string]string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	properties := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		idx := strings.IndexAny(line, "=:")
+		if idx <= 0 {
+			return nil, fmt.Errorf("%s:%d: expected name=value", filepath.Base(path), lineNum)
+		}
+
+		properties[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+	}
+
+	return properties, scanner.Err()
+}
+
+// applyAppProperties sets the values of the app properties, the values are converted to the type of the property
+func applyAppProperties(appObj map[string]interface{}, properties map[string]string) error {
+
+	declared := make(map[string]map[string]interface{})
+	for _, property := range getObjects(appObj, "properties") {
+		if name, ok := property["name"].(string); ok {
+			declared[name] = property
+		}
+	}
+
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := declared[name]
+		if !ok {
+			return fmt.Errorf("property '%s' isn't declared in %s", name, fileAIflowJson)
+		}
+
+		propertyType, _ := property["type"].(string)
+		val, err := coercePropertyValue(propertyType, properties[name])
+		if err != nil {
+			return fmt.Errorf("invalid value for property '%s': %v", name, err)
+		}
+		property["value"] = val
+	}
+
+	return nil
+}
+
+func coercePropertyValue(propertyType, value string) (interface{}, error) {
+
+	switch strings.ToLower(propertyType) {
+	case "int", "integer", "int32", "int64", "long":
+		return strconv.ParseInt(value, 10, 64)
+	case "float", "float32", "float64", "double", "number":
+		return strconv.ParseFloat(value, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(value)
+	case "any":
+		return parseSettingValue(value), nil
+	case "object", "array", "params":
+		var val interface{}
+		if err := json.Unmarshal([]byte(value), &val); err != nil {
+			return nil, err
+		}
+		return val, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+
+	target := map[string]interface{}{
+		"name":    "app",
+		"version": "0.0.1",
+		"settings": map[string]interface{}{
+			"port":  float64(8080),
+			"debug": true,
+		},
+	}
+	patch := map[string]interface{}{
+		"version": "1.0.0",
+		"settings": map[string]interface{}{
+			"port":  float64(80),
+			"debug": nil,
+		},
+	}
+
+	merged := mergePatch(target, patch).(map[string]interface{})
+	assert.Equal(t, "app", merged["name"])
+	assert.Equal(t, "1.0.0", merged["version"])
+	assert.Equal(t, map[string]interface{}{"port": float64(80)}, merged["settings"])
+
+	assert.Equal(t, "value", mergePatch(target, "value"))
+}
+
+func TestApplyAppProperties(t *testing.T) {
+
+	appObj := parseTestApp(t, `{
+  "properties": [
+    { "name": "port", "type": "int", "value": 8080 },
+    { "name": "host", "type": "string", "value": "localhost" }
+  ]
+}`)
+
+	err := applyAppProperties(appObj, map[string]string{"port": "80", "host": "example.com"})
+	assert.Nil(t, err)
+
+	properties := getObjects(appObj, "properties")
+	assert.Equal(t, int64(80), properties[0]["value"])
+	assert.Equal(t, "example.com", properties[1]["value"])
+
+	err = applyAppProperties(appObj, map[string]string{"port": "http"})
+	assert.NotNil(t, err)
+
+	err = applyAppProperties(appObj, map[string]string{"unknown": "value"})
+	assert.NotNil(t, err)
+}
+
+func TestRenderAppDescriptor(t *testing.T) {
+
+	tempDir := newWorkspaceTestDir(t, "app")
+	defer os.RemoveAll(tempDir)
+
+	appDir := filepath.Join(tempDir, "app")
+	appJson := "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, fileAIflowJson), []byte(appJson), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, fileEngineJson), []byte(`{"name": "app", "runnerType": "POOLED"}`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, engineOverlayFile("prod")), []byte(`{"runnerType": "DIRECT"}`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(appDir, appOverlayFile("dev")), []byte(`{"version": "1.0.0-dev"}`), 0644))
+
+	project := NewAppProject(appDir)
+
+	// an environment with only an engine overlay leaves AIflow.json unchanged
+	rendered, err := RenderAppDescriptor(project, "prod")
+	assert.Nil(t, err)
+	assert.Equal(t, appJson, rendered)
+
+	rendered, err = RenderEngineDescriptor(project, "prod")
+	assert.Nil(t, err)
+	assert.Contains(t, rendered, `"runnerType": "DIRECT"`)
+
+	rendered, err = RenderAppDescriptor(project, "dev")
+	assert.Nil(t, err)
+	assert.Contains(t, rendered, `"version": "1.0.0-dev"`)
+
+	_, err = RenderAppDescriptor(project, "test")
+	assert.EqualError(t, err, "no overlay found for environment 'test', expected AIflow.test.json, AIflow.test.properties or engine.test.json")
+
+	assert.Equal(t, []string{"dev", "prod"}, ListEnvs(project))
+}
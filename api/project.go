@@ -5,9 +5,10 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io/ioutil"
 	"os"
 	"path/filepath"
-	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
@@ -24,6 +25,8 @@ const (
 	dirBin         = "bin"
 )
 
+// Deprecated: GOOSENV is the GOOS of the environment when the CLI started, the API uses the GOOS of the session of
+// the project
 var GOOSENV = os.Getenv("GOOS")
 
 type appProjectImpl struct {
@@ -32,13 +35,20 @@ type appProjectImpl struct {
 	srcDir  string
 	binDir  string
 	dm      util.DepManager
+	session *common.Session
 }
 
+// NewAppProject returns the project of the app directory bound to the default session
 func NewAppProject(appDir string) common.AppProject {
-	project := &appProjectImpl{appDir: appDir}
+	return NewAppProjectInSession(common.DefaultSession(), appDir)
+}
+
+// NewAppProjectInSession returns the project of the app directory bound to the session
+func NewAppProjectInSession(session *common.Session, appDir string) common.AppProject {
+	project := &appProjectImpl{appDir: appDir, session: session}
 	project.srcDir = filepath.Join(appDir, dirSrc)
 	project.binDir = filepath.Join(appDir, dirBin)
-	project.dm = util.NewDepManager(project.srcDir)
+	project.dm = util.NewDepManagerInEnv(project.srcDir, session)
 	project.appName = filepath.Base(appDir)
 	return project
 }
@@ -64,6 +74,20 @@ func (p *appProjectImpl) Validate() error {
 		return fmt.Errorf("AIflow app directory corrupt, missing 'src/go.mod' file")
 	}
 
+	if len(common.Hooks()) > 0 {
+		buf, err := ioutil.ReadFile(filepath.Join(p.appDir, fileAIflowJson))
+		if err != nil {
+			return err
+		}
+
+		descriptor, err := util.ParseAppDescriptor(string(buf))
+		if err != nil {
+			return err
+		}
+
+		return fireEvent(&common.ValidateEvent{Project: p, Descriptor: descriptor})
+	}
+
 	return nil
 }
 
@@ -71,6 +95,11 @@ func (p *appProjectImpl) Name() string {
 	return p.appName
 }
 
+// Session returns the session the project is bound to
+func (p *appProjectImpl) Session() *common.Session {
+	return p.session
+}
+
 func (p *appProjectImpl) Dir() string {
 	return p.appDir
 }
@@ -93,7 +122,7 @@ func (p *appProjectImpl) Executable() string {
 
 	execPath = filepath.Join(p.binDir, p.appName)
 
-	if GOOSENV == "windows" || (runtime.GOOS == "windows" && GOOSENV == "") {
+	if p.session.GOOS() == "windows" {
 		// env or cross platform is windows
 		execPath = filepath.Join(p.binDir, p.appName+".exe")
 	}
@@ -123,7 +152,12 @@ func (p *appProjectImpl) GetGoImports(withVersion bool) ([]util.Import, error) {
 	var imports []util.Import
 	for _, is := range file.Imports {
 
-		imp, err := util.ParseImport(is.Path.Value)
+		path, err := strconv.Unquote(is.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		imp, err := util.ParseImport(path)
 		if err != nil {
 			return nil, err
 		}
@@ -147,11 +181,11 @@ func (p *appProjectImpl) addImportsInGo(ignoreError bool, imports ...util.Import
 		err := p.DepManager().AddDependency(i)
 		if err != nil {
 			if ignoreError {
-				fmt.Printf("Warning: unable to install '%s'\n", i)
+				printf(p, "Warning: unable to install '%s'\n", i)
 				continue
 			}
 
-			fmt.Fprintf(os.Stderr, "Error in installing '%s'\n", i)
+			eprintf(p, "Error in installing '%s'\n", i)
 
 			return err
 		}
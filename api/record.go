@@ -0,0 +1,529 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	fileRecordAppGo = "recordapp.go"
+	dirFixtures     = "fixtures"
+)
+
+// RecordOptions selects the activities whose inputs and outputs are recorded and the activities whose outputs
+// are replayed from the recorded fixtures. Activities are identified by their ref or import alias.
+type RecordOptions struct {
+	FixturesDir string
+	Record      []string // activities to record, all activities are recorded if empty
+	Replay      []string // activities to replay instead of being evaluated
+}
+
+// BuildRecordingProject builds the app with a wrapper that records the activity inputs and outputs to fixture
+// files, or replays the recorded outputs. The configuration is always embedded.
+func BuildRecordingProject(project common.AppProject, recordOptions RecordOptions, options common.BuildOptions) error {
+
+	if options.Shim != "" {
+		return fmt.Errorf("recording is not supported for shim builds")
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), true)
+	if err != nil {
+		return err
+	}
+
+	record, err := resolveActivityRefs(appImports, recordOptions.Record)
+	if err != nil {
+		return err
+	}
+
+	replay, err := resolveActivityRefs(appImports, recordOptions.Replay)
+	if err != nil {
+		return err
+	}
+
+	fixturesDir := recordOptions.FixturesDir
+	if fixturesDir == "" {
+		fixturesDir = filepath.Join(project.Dir(), dirFixtures)
+	}
+	fixturesDir, err = filepath.Abs(fixturesDir)
+	if err != nil {
+		return err
+	}
+
+	aliases := make(map[string]string)
+	for _, details := range appImports.GetAllImportDetails() {
+		if details.TopLevel && importContribType(details) == "activity" {
+			aliases["#"+details.Imp.CanonicalAlias()] = details.Imp.GoImportPath()
+		}
+	}
+
+	data := struct {
+		FixturesDir string
+		RecordAll   bool
+		Aliases     string
+		Record      string
+		Replay      string
+	}{
+		FixturesDir: strconv.Quote(fixturesDir),
+		RecordAll:   len(record) == 0,
+		Aliases:     goStringMap(aliases),
+		Record:      goStringSet(record),
+		Replay:      goStringSet(replay),
+	}
+
+	recordSrcPath := filepath.Join(project.SrcDir(), fileRecordAppGo)
+
+	logf(project, "Creating activity recording wrapper...\n")
+
+	f, err := os.Create(recordSrcPath)
+	if err != nil {
+		return err
+	}
+	RenderTemplate(f, tplRecordAppGoFile, &data)
+	_ = f.Close()
+
+	defer func() {
+		if err := util.DeleteFile(recordSrcPath); err != nil {
+			eprintf(project, "Unable to delete: %s\n", fileRecordAppGo)
+		}
+	}()
+
+	options.EmbedConfig = true
+
+	_, err = BuildProject(project, options)
+	if err != nil {
+		return err
+	}
+
+	if len(replay) > 0 {
+		printf(project, "Built app replaying recorded outputs from: %s\n", fixturesDir)
+	} else {
+		printf(project, "Built app recording activities to: %s\n", fixturesDir)
+	}
+
+	return nil
+}
+
+// resolveActivityRefs resolves the activity aliases to their import paths
+func resolveActivityRefs(appImports *util.AppImports, refs []string) ([]string, error) {
+
+	var resolved []string
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		if ref[0] == '#' {
+			details, err := findAliasedImport(appImports, ref[1:], "activity")
+			if err != nil {
+				return nil, err
+			}
+			ref = details.Imp.GoImportPath()
+		} else {
+			imp, err := util.ParseImport(ref)
+			if err != nil {
+				return nil, err
+			}
+			ref = imp.GoImportPath()
+		}
+
+		resolved = append(resolved, ref)
+	}
+
+	return resolved, nil
+}
+
+func goStringMap(m map[string]string) string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []string
+	for _, key := range keys {
+		entries = append(entries, strconv.Quote(key)+": "+strconv.Quote(m[key]))
+	}
+
+	return "map[string]string{" + strings.Join(entries, ", ") + "}"
+}
+
+func goStringSet(values []string) string {
+	sort.Strings(values)
+
+	var entries []string
+	for _, val := range values {
+		entries = append(entries, strconv.Quote(val)+": true")
+	}
+
+	return "map[string]bool{" + strings.Join(entries, ", ") + "}"
+}
+
+var tplRecordAppGoFile = `// Do not change this file, it has been generated using AIflow-cli
+// If you change it and rebuild the application your changes might get lost
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r2d2-ai/aiflow/activity"
+	"github.com/r2d2-ai/aiflow/data"
+)
+
+const aiflowFixturesDir = {{.FixturesDir}}
+const aiflowRecordAll = {{.RecordAll}}
+
+// activity import aliases used by the app
+var aiflowRecordAliases = {{.Aliases}}
+
+var aiflowRecordRefs = {{.Record}}
+var aiflowReplayRefs = {{.Replay}}
+
+var aiflowFixtureLock sync.Mutex
+
+type aiflowRecording struct {
+	Ref     string                 ` + "`json:\"ref\"`" + `
+	Flow    string                 ` + "`json:\"flow\"`" + `
+	Task    string                 ` + "`json:\"task\"`" + `
+	Inputs  map[string]interface{} ` + "`json:\"inputs\"`" + `
+	Outputs map[string]interface{} ` + "`json:\"outputs\"`" + `
+	Error   string                 ` + "`json:\"error,omitempty\"`" + `
+	Time    time.Time              ` + "`json:\"time\"`" + `
+}
+
+// aiflowRecordActivity wraps an activity, recording its inputs and outputs or replaying recorded outputs
+type aiflowRecordActivity struct {
+	inner   activity.Activity
+	ref     string
+	flow    string
+	task    string
+	replay  bool
+	fixture string
+
+	recordings []*aiflowRecording
+	loaded     bool
+}
+
+var aiflowRecordMd = activity.ToMetadata()
+
+func (a *aiflowRecordActivity) Metadata() *activity.Metadata {
+	if a.inner != nil {
+		return a.inner.Metadata()
+	}
+	return aiflowRecordMd
+}
+
+func (a *aiflowRecordActivity) Eval(ctx activity.Context) (bool, error) {
+
+	if a.replay {
+		return a.replayOutputs(ctx)
+	}
+
+	rctx := &aiflowRecordContext{Context: ctx, inputs: make(map[string]interface{}), outputs: make(map[string]interface{})}
+	done, err := a.inner.Eval(rctx)
+
+	rec := &aiflowRecording{Ref: a.ref, Flow: a.flow, Task: a.task, Inputs: rctx.inputs, Outputs: rctx.outputs, Time: time.Now()}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if werr := aiflowWriteRecording(a.fixture, rec); werr != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record activity '%s': %v\n", a.task, werr)
+	}
+
+	return done, err
+}
+
+func (a *aiflowRecordActivity) replayOutputs(ctx activity.Context) (bool, error) {
+
+	aiflowFixtureLock.Lock()
+	if !a.loaded {
+		a.recordings = aiflowReadRecordings(a.fixture)
+		a.loaded = true
+	}
+	recordings := a.recordings
+	aiflowFixtureLock.Unlock()
+
+	if len(recordings) == 0 {
+		return false, fmt.Errorf("no recorded outputs for task '%s' in %s", a.task, a.fixture)
+	}
+
+	// use the latest recording with the same inputs, otherwise the latest recording
+	match := recordings[len(recordings)-1]
+	for i := len(recordings) - 1; i >= 0; i-- {
+		if aiflowInputsMatch(ctx, recordings[i].Inputs) {
+			match = recordings[i]
+			break
+		}
+	}
+
+	if match.Error != "" {
+		return false, fmt.Errorf("%s", match.Error)
+	}
+
+	for name, val := range match.Outputs {
+		if err := ctx.SetOutput(name, val); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// aiflowRecordContext captures the inputs read and outputs set by the wrapped activity
+type aiflowRecordContext struct {
+	activity.Context
+	inputs  map[string]interface{}
+	outputs map[string]interface{}
+}
+
+func (c *aiflowRecordContext) GetInput(name string) interface{} {
+	val := c.Context.GetInput(name)
+	c.inputs[name] = val
+	return val
+}
+
+func (c *aiflowRecordContext) GetInputObject(input data.StructValue) error {
+	err := c.Context.GetInputObject(input)
+	if err == nil {
+		for name, val := range input.ToMap() {
+			c.inputs[name] = val
+		}
+	}
+	return err
+}
+
+func (c *aiflowRecordContext) SetOutput(name string, value interface{}) error {
+	c.outputs[name] = value
+	return c.Context.SetOutput(name, value)
+}
+
+func (c *aiflowRecordContext) SetOutputObject(output data.StructValue) error {
+	for name, val := range output.ToMap() {
+		c.outputs[name] = val
+	}
+	return c.Context.SetOutputObject(output)
+}
+
+// aiflowInitContext provides the wrapped activity with its original settings
+type aiflowInitContext struct {
+	activity.InitContext
+	settings map[string]interface{}
+}
+
+func (c *aiflowInitContext) Settings() map[string]interface{} {
+	return c.settings
+}
+
+func newAIflowRecordActivity(ctx activity.InitContext) (activity.Activity, error) {
+
+	settings := ctx.Settings()
+	a := &aiflowRecordActivity{}
+	a.ref, _ = settings["aiflowRef"].(string)
+	a.flow, _ = settings["aiflowFlow"].(string)
+	a.task, _ = settings["aiflowTask"].(string)
+	a.replay, _ = settings["aiflowReplay"].(bool)
+	a.fixture, _ = settings["aiflowFixture"].(string)
+
+	innerSettings, _ := settings["aiflowSettings"].(map[string]interface{})
+	if innerSettings == nil {
+		innerSettings = make(map[string]interface{})
+	}
+
+	if f := activity.GetFactory(a.ref); f != nil {
+		inner, err := f(&aiflowInitContext{InitContext: ctx, settings: innerSettings})
+		if err != nil {
+			return nil, err
+		}
+		a.inner = inner
+	} else {
+		a.inner = activity.Get(a.ref)
+	}
+
+	if a.inner == nil && !a.replay {
+		return nil, fmt.Errorf("activity '%s' not installed", a.ref)
+	}
+
+	return a, nil
+}
+
+func init() {
+	_ = activity.Register(&aiflowRecordActivity{}, newAIflowRecordActivity)
+}
+
+// the activities are wrapped when the embedded configuration is decoded, whatever the order the init functions of
+// the generated files run in
+var aiflowRecordFilter = func() bool {
+	embeddedConfigFilters = append(embeddedConfigFilters, aiflowRecordConfig)
+	return true
+}()
+
+func aiflowRecordConfig(appJson string) string {
+	updated, err := aiflowWrapActivities(appJson)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to setup activity recording: %v\n", err)
+		return appJson
+	}
+	return updated
+}
+
+// aiflowWrapActivities replaces the activity of the recorded and replayed tasks with the wrapper activity
+func aiflowWrapActivities(appJson string) (string, error) {
+
+	var appObj map[string]interface{}
+	if err := json.Unmarshal([]byte(appJson), &appObj); err != nil {
+		return "", err
+	}
+
+	fixturesDir := os.Getenv("AIFLOW_FIXTURES_DIR")
+	if fixturesDir == "" {
+		fixturesDir = aiflowFixturesDir
+	}
+
+	wrapperRef := activity.GetRef(&aiflowRecordActivity{})
+	resources, _ := appObj["resources"].([]interface{})
+
+	for _, res := range resources {
+		resMap, _ := res.(map[string]interface{})
+		flowId, _ := resMap["id"].(string)
+		flowData, _ := resMap["data"].(map[string]interface{})
+		if !strings.HasPrefix(flowId, "flow:") || flowData == nil {
+			continue
+		}
+
+		var tasks []interface{}
+		if ts, ok := flowData["tasks"].([]interface{}); ok {
+			tasks = append(tasks, ts...)
+		}
+		if errorHandler, ok := flowData["errorHandler"].(map[string]interface{}); ok {
+			if ts, ok := errorHandler["tasks"].([]interface{}); ok {
+				tasks = append(tasks, ts...)
+			}
+		}
+
+		for _, task := range tasks {
+			taskMap, _ := task.(map[string]interface{})
+			taskId, _ := taskMap["id"].(string)
+			act, _ := taskMap["activity"].(map[string]interface{})
+			ref, _ := act["ref"].(string)
+			if ref == "" {
+				continue
+			}
+			if resolved, ok := aiflowRecordAliases[ref]; ok {
+				ref = resolved
+			}
+
+			replay := aiflowReplayRefs[ref]
+			if !replay && !aiflowRecordAll && !aiflowRecordRefs[ref] {
+				continue
+			}
+
+			act["ref"] = wrapperRef
+			act["settings"] = map[string]interface{}{
+				"aiflowRef":      ref,
+				"aiflowFlow":     flowId,
+				"aiflowTask":     taskId,
+				"aiflowReplay":   replay,
+				"aiflowSettings": act["settings"],
+				"aiflowFixture":  filepath.Join(fixturesDir, aiflowFileName(flowId), aiflowFileName(taskId)+".jsonl"),
+			}
+		}
+	}
+
+	buf, err := json.Marshal(appObj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func aiflowFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func aiflowWriteRecording(fixture string, rec *aiflowRecording) error {
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	aiflowFixtureLock.Lock()
+	defer aiflowFixtureLock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(fixture), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fixture, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(buf, '\n'))
+	return err
+}
+
+func aiflowReadRecordings(fixture string) []*aiflowRecording {
+	f, err := os.Open(fixture)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var recordings []*aiflowRecording
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		rec := &aiflowRecording{}
+		if err := json.Unmarshal(scanner.Bytes(), rec); err == nil {
+			recordings = append(recordings, rec)
+		}
+	}
+
+	return recordings
+}
+
+func aiflowInputsMatch(ctx activity.Context, inputs map[string]interface{}) bool {
+	for name, val := range inputs {
+		if !reflect.DeepEqual(aiflowNormalize(ctx.GetInput(name)), aiflowNormalize(val)) {
+			return false
+		}
+	}
+	return true
+}
+
+func aiflowNormalize(val interface{}) interface{} {
+	buf, err := json.Marshal(val)
+	if err != nil {
+		return val
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(buf, &normalized); err != nil {
+		return val
+	}
+	return normalized
+}
+`
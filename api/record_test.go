@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// identifiers returns the identifiers used by the node
+func identifiers(node ast.Node) map[string]bool {
+	idents := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			idents[ident.Name] = true
+		}
+		return true
+	})
+	return idents
+}
+
+func TestRecordAppGoFile(t *testing.T) {
+
+	data := struct {
+		FixturesDir string
+		RecordAll   bool
+		Aliases     string
+		Record      string
+		Replay      string
+	}{
+		FixturesDir: `"/tmp/fixtures"`,
+		RecordAll:   true,
+		Aliases:     goStringMap(map[string]string{"#log": "github.com/r2d2-ai/aiflow/activity/log"}),
+		Record:      goStringSet(nil),
+		Replay:      goStringSet([]string{"github.com/r2d2-ai/aiflow/activity/rest"}),
+	}
+
+	var buf bytes.Buffer
+	RenderTemplate(&buf, tplRecordAppGoFile, &data)
+	recordFile, err := parser.ParseFile(token.NewFileSet(), fileRecordAppGo, buf.Bytes(), 0)
+	assert.Nil(t, err)
+
+	buf.Reset()
+	RenderTemplate(&buf, tplEmbeddedAppGoFile, &embeddedConfig{AIflowJSON: goBytesLiteral(nil), EngineJSON: goBytesLiteral(nil), NewMain: true})
+	embeddedFile, err := parser.ParseFile(token.NewFileSet(), fileEmbeddedAppGo, buf.Bytes(), 0)
+	assert.Nil(t, err)
+
+	// the recording doesn't depend on the order the init functions run in: its filter is registered by a variable
+	// initializer and applied by the init of the embedded configuration
+	registered := false
+	for _, decl := range recordFile.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name == "init" {
+				assert.False(t, identifiers(decl)["cfgJson"])
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.VAR && identifiers(decl)["embeddedConfigFilters"] {
+				registered = true
+			}
+		}
+	}
+	assert.True(t, registered)
+
+	applied := false
+	for _, decl := range embeddedFile.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "init" {
+			idents := identifiers(fn)
+			applied = idents["cfgJson"] && idents["embeddedConfigFilters"]
+		}
+	}
+	assert.True(t, applied)
+}
+
+// harness of TestRecordReplay, run with the generated recording wrapper
+var recordHarnessGoFile = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow/activity"
+)
+
+// declared by the embedded configuration of the app
+var embeddedConfigFilters []func(string) string
+
+type harnessContext struct {
+	activity.Context
+	inputs  map[string]interface{}
+	outputs map[string]interface{}
+}
+
+func (c *harnessContext) GetInput(name string) interface{} {
+	return c.inputs[name]
+}
+
+func (c *harnessContext) SetOutput(name string, value interface{}) error {
+	c.outputs[name] = value
+	return nil
+}
+
+type greetActivity struct{}
+
+func (*greetActivity) Metadata() *activity.Metadata {
+	return nil
+}
+
+func (*greetActivity) Eval(ctx activity.Context) (bool, error) {
+	return true, ctx.SetOutput("message", fmt.Sprintf("hello %v", ctx.GetInput("name")))
+}
+
+func eval(a activity.Activity, name string) interface{} {
+	ctx := &harnessContext{inputs: map[string]interface{}{"name": name}, outputs: make(map[string]interface{})}
+	if _, err := a.Eval(ctx); err != nil {
+		return err.Error()
+	}
+	return ctx.outputs["message"]
+}
+
+func main() {
+
+	appJson := ` + "`" + `{"resources": [{"id": "flow:greet", "data": {"tasks": [{"id": "say", "activity": {"ref": "#greet", "settings": {"lang": "en"}}}]}}]}` + "`" + `
+	for _, filter := range embeddedConfigFilters {
+		appJson = filter(appJson)
+	}
+
+	var appObj map[string]interface{}
+	if err := json.Unmarshal([]byte(appJson), &appObj); err != nil {
+		panic(err)
+	}
+	task := appObj["resources"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})["tasks"].([]interface{})[0]
+	act := task.(map[string]interface{})["activity"].(map[string]interface{})
+	settings := act["settings"].(map[string]interface{})
+	fixture := settings["aiflowFixture"].(string)
+
+	recorder := &aiflowRecordActivity{inner: &greetActivity{}, ref: settings["aiflowRef"].(string), task: "say", fixture: fixture}
+	eval(recorder, "world")
+	eval(recorder, "bob")
+
+	replayer := &aiflowRecordActivity{ref: settings["aiflowRef"].(string), task: "say", replay: true, fixture: fixture}
+
+	_ = json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+		"wrapped":  act["ref"] == activity.GetRef(&aiflowRecordActivity{}),
+		"ref":      settings["aiflowRef"],
+		"settings": settings["aiflowSettings"],
+		"fixture":  fixture,
+		"bob":      eval(replayer, "bob"),
+		"alice":    eval(replayer, "alice"),
+	})
+}
+`
+
+func TestRecordReplay(t *testing.T) {
+
+	// the wrapper is run against the aiflow module the CLI is built with
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/r2d2-ai/aiflow").Output()
+	aiflowDir := strings.TrimSpace(string(out))
+	if err != nil || aiflowDir == "" {
+		t.Skip("the aiflow module isn't available")
+	}
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	fixturesDir := filepath.Join(tempDir, dirFixtures)
+	data := struct {
+		FixturesDir string
+		RecordAll   bool
+		Aliases     string
+		Record      string
+		Replay      string
+	}{
+		FixturesDir: strconv.Quote(fixturesDir),
+		RecordAll:   true,
+		Aliases:     goStringMap(map[string]string{"#greet": "example.com/greet"}),
+		Record:      goStringSet(nil),
+		Replay:      goStringSet(nil),
+	}
+
+	var buf bytes.Buffer
+	RenderTemplate(&buf, tplRecordAppGoFile, &data)
+
+	goMod := "module recordtest\n\ngo 1.16\n\nrequire github.com/r2d2-ai/aiflow v0.1.1\n\nreplace github.com/r2d2-ai/aiflow => " + aiflowDir + "\n"
+	files := map[string]string{fileRecordAppGo: buf.String(), fileMainGo: recordHarnessGoFile, "go.mod": goMod}
+	for name, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644))
+	}
+	if goSum, err := ioutil.ReadFile(filepath.Join("..", "go.sum")); err == nil {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, "go.sum"), goSum, 0644))
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = tempDir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "AIFLOW_FIXTURES_DIR=")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err = cmd.Output()
+	if !assert.Nil(t, err, stderr.String()) {
+		return
+	}
+
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out, &result))
+
+	// the task is wrapped, keeping its ref and settings
+	assert.Equal(t, true, result["wrapped"])
+	assert.Equal(t, "example.com/greet", result["ref"])
+	assert.Equal(t, map[string]interface{}{"lang": "en"}, result["settings"])
+
+	// the recordings are written to the fixture of the task
+	fixture := filepath.Join(fixturesDir, "flow_greet", "say.jsonl")
+	assert.Equal(t, fixture, result["fixture"])
+	recorded, err := ioutil.ReadFile(fixture)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(recorded), "\n"))
+
+	// the replay uses the recording with the same inputs, the latest one otherwise
+	assert.Equal(t, "hello bob", result["bob"])
+	assert.Equal(t, "hello bob", result["alice"])
+}
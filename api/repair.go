@@ -0,0 +1,416 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// ProjectRepair is an inconsistency found in a project and the fix restoring a consistent state
+type ProjectRepair struct {
+	Problem string
+	Fix     string
+	apply   func() error
+}
+
+type repairCheck func(project common.AppProject) (*ProjectRepair, error)
+
+// the checks are ordered so that each one can rely on the fixes of the previous ones
+var repairChecks = []repairCheck{
+	checkSrcFiles,
+	checkShimLeftovers,
+	checkMainGo,
+	checkOptimizeLeftovers,
+	checkStaleEmbeddedApp,
+	checkGeneratedLeftovers,
+	checkImportsSync,
+	checkGoModRequires,
+}
+
+// RepairProject detects the inconsistencies left by interrupted commands and restores a consistent project,
+// with dryRun the repairs are only reported
+func RepairProject(project common.AppProject, dryRun bool) ([]*ProjectRepair, error) {
+
+	if !util.FileExists(filepath.Join(project.Dir(), fileAIflowJson)) {
+		return nil, fmt.Errorf("not a valid AIflow app project directory, missing %s", fileAIflowJson)
+	}
+
+	if _, err := readAppDescriptor(project); err != nil {
+		return nil, fmt.Errorf("invalid %s, it must be fixed manually: %v", fileAIflowJson, err)
+	}
+
+	var repairs []*ProjectRepair
+	for _, check := range repairChecks {
+		repair, err := check(project)
+		if err != nil {
+			return repairs, err
+		}
+		if repair == nil {
+			continue
+		}
+
+		repairs = append(repairs, repair)
+		if dryRun {
+			printf(project, "%s\n  would %s\n", repair.Problem, repair.Fix)
+			continue
+		}
+
+		printf(project, "%s\n  %s\n", repair.Problem, repair.Fix)
+		err = repair.apply()
+		if err != nil {
+			return repairs, fmt.Errorf("unable to %s: %v", repair.Fix, err)
+		}
+	}
+
+	if len(repairs) == 0 {
+		printf(project, "No problems found\n")
+	}
+
+	return repairs, nil
+}
+
+func checkSrcFiles(project common.AppProject) (*ProjectRepair, error) {
+
+	if !util.DirExists(project.SrcDir()) {
+		return nil, fmt.Errorf("missing 'src' directory, recreate the project with 'AIflow create -f %s'", fileAIflowJson)
+	}
+
+	importsGo := filepath.Join(project.SrcDir(), fileImportsGo)
+	goMod := filepath.Join(project.SrcDir(), "go.mod")
+
+	var missing []string
+	if !util.FileExists(importsGo) {
+		missing = append(missing, "src/"+fileImportsGo)
+	}
+	if !util.FileExists(goMod) {
+		missing = append(missing, "src/go.mod")
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	return &ProjectRepair{
+		Problem: "missing " + strings.Join(missing, ", "),
+		Fix:     "recreate " + strings.Join(missing, ", "),
+		apply: func() error {
+			if !util.FileExists(importsGo) {
+				err := ioutil.WriteFile(importsGo, []byte("package main\n"), 0644)
+				if err != nil {
+					return err
+				}
+			}
+			if !util.FileExists(goMod) {
+				err := project.DepManager().Init()
+				if err != nil {
+					return err
+				}
+				return project.DepManager().AddDependency(util.NewAIflowImport(AIflowCoreRepo, "", "", ""))
+			}
+			return nil
+		},
+	}, nil
+}
+
+func checkShimLeftovers(project common.AppProject) (*ProjectRepair, error) {
+
+	var leftovers []string
+	for _, file := range []string{fileShimSupportGo, fileShimGo, fileBuildGo} {
+		if util.FileExists(filepath.Join(project.SrcDir(), file)) {
+			leftovers = append(leftovers, file)
+		}
+	}
+
+	if len(leftovers) == 0 {
+		return nil, nil
+	}
+
+	return &ProjectRepair{
+		Problem: "shim support files left by an interrupted shim build: src/" + strings.Join(leftovers, ", src/"),
+		Fix:     "remove the shim support files",
+		apply: func() error {
+			shimCleanup(project)
+			return nil
+		},
+	}, nil
+}
+
+func checkMainGo(project common.AppProject) (*ProjectRepair, error) {
+
+	mainGo := filepath.Join(project.SrcDir(), fileMainGo)
+	mainGoBak := mainGo + ".bak"
+
+	switch {
+	case util.FileExists(mainGoBak) && !util.FileExists(mainGo):
+		return &ProjectRepair{
+			Problem: "src/" + fileMainGo + " left renamed by an interrupted shim build",
+			Fix:     "restore src/" + fileMainGo + " from src/" + fileMainGo + ".bak",
+			apply: func() error {
+				return restoreMain(project)
+			},
+		}, nil
+	case util.FileExists(mainGoBak):
+		// like backupMain, the backup is considered the original main of the app
+		return &ProjectRepair{
+			Problem: "src/" + fileMainGo + ".bak left by an interrupted shim build along with src/" + fileMainGo,
+			Fix:     "restore src/" + fileMainGo + " from src/" + fileMainGo + ".bak",
+			apply: func() error {
+				return os.Rename(mainGoBak, mainGo)
+			},
+		}, nil
+	case !util.FileExists(mainGo):
+		return &ProjectRepair{
+			Problem: "missing src/" + fileMainGo,
+			Fix:     "recreate src/" + fileMainGo + " from the core library",
+			apply: func() error {
+				return createMain(project.DepManager(), project.Dir())
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func checkOptimizeLeftovers(project common.AppProject) (*ProjectRepair, error) {
+
+	if !util.FileExists(filepath.Join(project.SrcDir(), fileImportsGo+".orig")) {
+		return nil, nil
+	}
+
+	return &ProjectRepair{
+		Problem: "src/" + fileImportsGo + ".orig left by an interrupted optimized or reproducible build",
+		Fix:     "restore src/" + fileImportsGo + " from src/" + fileImportsGo + ".orig",
+		apply: func() error {
+			restoreImports(project)
+			return nil
+		},
+	}, nil
+}
+
+func checkStaleEmbeddedApp(project common.AppProject) (*ProjectRepair, error) {
+
+	embedSrcPath := filepath.Join(project.SrcDir(), fileEmbeddedAppGo)
+	if !util.FileExists(embedSrcPath) {
+		return nil, nil
+	}
+
+	embedded, err := ioutil.ReadFile(embedSrcPath)
+	if err != nil {
+		return nil, err
+	}
+	appJson, err := ioutil.ReadFile(filepath.Join(project.Dir(), fileAIflowJson))
+	if err != nil {
+		return nil, err
+	}
+
+	// the digest of the embedded descriptor is recorded since it may be compressed or encrypted, files generated
+	// by older versions embed it as a string
+	digest := embeddedAppDigest(embedded)
+	isEmbedded := func(appJson string) bool {
+		if digest != "" {
+			return digest == configDigest(appJson)
+		}
+		return strings.Contains(string(embedded), appJson)
+	}
+
+	if isEmbedded(string(appJson)) {
+		return nil, nil
+	}
+
+	// the embedded descriptor may have the overlays of an environment applied
+	for _, env := range ListEnvs(project) {
+		if rendered, err := RenderAppDescriptor(project, env); err == nil && isEmbedded(rendered) {
+			return nil, nil
+		}
+	}
+
+	return &ProjectRepair{
+		Problem: "src/" + fileEmbeddedAppGo + " embeds an outdated " + fileAIflowJson,
+		Fix:     "remove src/" + fileEmbeddedAppGo + ", it is recreated by 'AIflow build --embed'",
+		apply: func() error {
+			return cleanupEmbeddedAppGoFile(project)
+		},
+	}, nil
+}
+
+func checkGeneratedLeftovers(project common.AppProject) (*ProjectRepair, error) {
+
+	var leftovers []string
+	for _, file := range []string{fileRecordAppGo, fileFlowTestGo, fileBuildInfoGo} {
+		if util.FileExists(filepath.Join(project.SrcDir(), file)) {
+			leftovers = append(leftovers, file)
+		}
+	}
+
+	if len(leftovers) == 0 {
+		return nil, nil
+	}
+
+	return &ProjectRepair{
+		Problem: "generated files left by an interrupted command: src/" + strings.Join(leftovers, ", src/"),
+		Fix:     "remove the generated files",
+		apply: func() error {
+			for _, file := range leftovers {
+				if err := util.DeleteFile(filepath.Join(project.SrcDir(), file)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func checkImportsSync(project common.AppProject) (*ProjectRepair, error) {
+
+	// only reached with a dry run when src/imports.go is missing
+	if !util.FileExists(filepath.Join(project.SrcDir(), fileImportsGo)) {
+		return nil, nil
+	}
+
+	appImports, err := util.GetAppImports(filepath.Join(project.Dir(), fileAIflowJson), project.DepManager(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	goImports, err := project.GetGoImports(false)
+	if err != nil {
+		return nil, err
+	}
+
+	appImportsMap := make(map[string]bool)
+	for _, imp := range appImports.GetAllImports() {
+		appImportsMap[imp.GoImportPath()] = true
+	}
+
+	// the imports of the engine services are expected as well
+	if util.FileExists(filepath.Join(project.Dir(), fileEngineJson)) {
+		engineImports, err := util.GetEngineImports(filepath.Join(project.Dir(), fileEngineJson), project.DepManager())
+		if err != nil {
+			return nil, err
+		}
+		for _, imp := range engineImports.GetAllImports() {
+			appImportsMap[imp.GoImportPath()] = true
+		}
+	}
+	goImportsMap := make(map[string]bool)
+	for _, imp := range goImports {
+		goImportsMap[imp.GoImportPath()] = true
+	}
+
+	var problems []string
+	for goPath := range appImportsMap {
+		if !goImportsMap[goPath] {
+			problems = append(problems, "missing "+goPath)
+		}
+	}
+	for goPath := range goImportsMap {
+		if !appImportsMap[goPath] {
+			problems = append(problems, "extraneous "+goPath)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil, nil
+	}
+	sort.Strings(problems)
+
+	return &ProjectRepair{
+		Problem: "src/" + fileImportsGo + " doesn't match the " + fileAIflowJson + " imports (" + strings.Join(problems, ", ") + ")",
+		Fix:     "synchronize src/" + fileImportsGo + " with the " + fileAIflowJson + " imports",
+		apply: func() error {
+			return SyncProjectImports(project)
+		},
+	}, nil
+}
+
+func checkGoModRequires(project common.AppProject) (*ProjectRepair, error) {
+
+	// only reached with a dry run when src/imports.go or src/go.mod is missing
+	if !util.FileExists(filepath.Join(project.SrcDir(), fileImportsGo)) || !util.FileExists(filepath.Join(project.SrcDir(), "go.mod")) {
+		return nil, nil
+	}
+
+	goImports, err := project.GetGoImports(false)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := readGoModRequires(filepath.Join(project.SrcDir(), "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, imp := range goImports {
+		found := false
+		for _, mod := range required {
+			if imp.GoImportPath() == mod || strings.HasPrefix(imp.GoImportPath(), mod+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, imp.GoImportPath())
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	return &ProjectRepair{
+		Problem: "src/go.mod doesn't require the modules of " + strings.Join(missing, ", "),
+		Fix:     "add the missing requirements with 'go mod tidy'",
+		apply: func() error {
+			return execCmd(project, exec.Command("go", "mod", "tidy"), project.SrcDir())
+		},
+	}, nil
+}
+
+// readGoModRequires returns the module paths required and replaced in a go.mod file
+func readGoModRequires(goModFile string) ([]string, error) {
+
+	f, err := os.Open(goModFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var modules []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			modules = append(modules, fields[0])
+		case (fields[0] == "require" || fields[0] == "replace") && len(fields) > 1:
+			if fields[1] == "(" {
+				inBlock = true
+			} else {
+				modules = append(modules, fields[1])
+			}
+		}
+	}
+
+	return modules, scanner.Err()
+}
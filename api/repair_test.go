@@ -0,0 +1,291 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadGoModRequires(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	goMod := filepath.Join(tempDir, "go.mod")
+	err = ioutil.WriteFile(goMod, []byte(`module main
+
+go 1.16
+
+require github.com/r2d2-ai/aiflow v1.0.0
+
+require (
+	github.com/r2d2-ai/aiflow/contrib v0.1.0 // indirect
+	github.com/stretchr/testify v1.7.0
+)
+
+replace github.com/r2d2-ai/aiflow/contrib => ../contrib
+`), 0644)
+	assert.Nil(t, err)
+
+	modules, err := readGoModRequires(goMod)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"github.com/r2d2-ai/aiflow", "github.com/r2d2-ai/aiflow/contrib", "github.com/stretchr/testify", "github.com/r2d2-ai/aiflow/contrib"}, modules)
+}
+
+var repairAppJson = `{
+  "name": "temp",
+  "type": "AIflow:app",
+  "imports": [
+    "github.com/r2d2-ai/aiflow/activity/common/log"
+  ]
+}
+`
+
+var repairImportsGo = "package main\n\nimport (\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/log\"\n)\n"
+
+// newRepairTestProject creates a consistent app project, its repairs don't require the network
+func newRepairTestProject(t *testing.T) (string, string) {
+
+	tempDir := newWorkspaceTestDir(t, "app")
+	appDir := filepath.Join(tempDir, "app")
+	srcDir := filepath.Join(appDir, dirSrc)
+
+	files := map[string]string{
+		filepath.Join(appDir, fileAIflowJson): repairAppJson,
+		filepath.Join(srcDir, fileImportsGo):  repairImportsGo,
+		filepath.Join(srcDir, "go.mod"):       "module main\n\ngo 1.16\n\nrequire github.com/r2d2-ai/aiflow v1.0.0\n",
+		filepath.Join(srcDir, fileMainGo):     "package main\n",
+	}
+	for file, content := range files {
+		assert.Nil(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	return tempDir, appDir
+}
+
+// readDirFiles returns the content of the files of a directory tree
+func readDirFiles(t *testing.T, dir string) map[string]string {
+
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		files[path] = string(content)
+		return err
+	})
+	assert.Nil(t, err)
+
+	return files
+}
+
+func TestRepairProject(t *testing.T) {
+
+	tempDir, appDir := newRepairTestProject(t)
+	defer os.RemoveAll(tempDir)
+	srcDir := filepath.Join(appDir, dirSrc)
+
+	project := NewAppProject(appDir)
+
+	repairs, err := RepairProject(project, false)
+	assert.Nil(t, err)
+	assert.Len(t, repairs, 0)
+
+	// the leftovers of an interrupted shim build, optimized build and test, the restored imports have an
+	// extraneous import synchronized afterwards
+	extraneousImportsGo := "package main\n\nimport (\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/log\"\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/noop\"\n)\n"
+	files := map[string]string{
+		fileShimGo:              "package main\n",
+		fileBuildGo:             "package main\n",
+		fileMainGo:              "package main\n\n// shim\n",
+		fileMainGo + ".bak":     "package main\n",
+		fileImportsGo:           "package main\n",
+		fileImportsGo + ".orig": extraneousImportsGo,
+		fileEmbeddedAppGo:       "package main\n\nconst cfgJson = `{}`\n",
+		fileFlowTestGo:          "package main\n",
+	}
+	for file, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(srcDir, file), []byte(content), 0644))
+	}
+
+	// a dry run doesn't touch the project
+	before := readDirFiles(t, tempDir)
+	repairs, err = RepairProject(project, true)
+	assert.Nil(t, err)
+	assert.Len(t, repairs, 6)
+	assert.Equal(t, before, readDirFiles(t, tempDir))
+
+	repairs, err = RepairProject(project, false)
+	assert.Nil(t, err)
+	assert.Len(t, repairs, 6)
+
+	for _, file := range []string{fileShimGo, fileBuildGo, fileMainGo + ".bak", fileImportsGo + ".orig", fileEmbeddedAppGo, fileFlowTestGo} {
+		assert.False(t, util.FileExists(filepath.Join(srcDir, file)), file)
+	}
+	mainGo, err := ioutil.ReadFile(filepath.Join(srcDir, fileMainGo))
+	assert.Nil(t, err)
+	assert.Equal(t, "package main\n", string(mainGo))
+	goImports, err := project.GetGoImports(false)
+	assert.Nil(t, err)
+	assert.Len(t, goImports, 1)
+
+	repairs, err = RepairProject(project, false)
+	assert.Nil(t, err)
+	assert.Len(t, repairs, 0)
+}
+
+func TestRepairChecks(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		check   repairCheck
+		setup   map[string]string
+		problem string
+		// the files of src after the repair, nil when the repair requires the network
+		repaired map[string]string
+	}{
+		{
+			name:     "missing imports.go",
+			check:    checkSrcFiles,
+			setup:    map[string]string{fileImportsGo: ""},
+			problem:  "missing src/" + fileImportsGo,
+			repaired: map[string]string{fileImportsGo: "package main\n"},
+		},
+		{
+			name:    "missing go.mod",
+			check:   checkSrcFiles,
+			setup:   map[string]string{"go.mod": ""},
+			problem: "missing src/go.mod",
+		},
+		{
+			name:     "shim support files",
+			check:    checkShimLeftovers,
+			setup:    map[string]string{fileShimSupportGo: "package main\n"},
+			problem:  "src/" + fileShimSupportGo,
+			repaired: map[string]string{fileShimSupportGo: ""},
+		},
+		{
+			name:     "shim build file",
+			check:    checkShimLeftovers,
+			setup:    map[string]string{fileBuildGo: "package main\n"},
+			problem:  "src/" + fileBuildGo,
+			repaired: map[string]string{fileBuildGo: ""},
+		},
+		{
+			name:     "renamed main.go",
+			check:    checkMainGo,
+			setup:    map[string]string{fileMainGo: "", fileMainGo + ".bak": "package main\n\n// app\n"},
+			problem:  "left renamed",
+			repaired: map[string]string{fileMainGo: "package main\n\n// app\n", fileMainGo + ".bak": ""},
+		},
+		{
+			name:     "main.go and its backup",
+			check:    checkMainGo,
+			setup:    map[string]string{fileMainGo: "package main\n\n// shim\n", fileMainGo + ".bak": "package main\n\n// app\n"},
+			problem:  "along with src/" + fileMainGo,
+			repaired: map[string]string{fileMainGo: "package main\n\n// app\n", fileMainGo + ".bak": ""},
+		},
+		{
+			name:    "missing main.go",
+			check:   checkMainGo,
+			setup:   map[string]string{fileMainGo: ""},
+			problem: "missing src/" + fileMainGo,
+		},
+		{
+			name:     "imports.go backup",
+			check:    checkOptimizeLeftovers,
+			setup:    map[string]string{fileImportsGo: "package main\n", fileImportsGo + ".orig": repairImportsGo},
+			problem:  "src/" + fileImportsGo + ".orig",
+			repaired: map[string]string{fileImportsGo: repairImportsGo, fileImportsGo + ".orig": ""},
+		},
+		{
+			name:     "outdated embedded app",
+			check:    checkStaleEmbeddedApp,
+			setup:    map[string]string{fileEmbeddedAppGo: "package main\n\nconst cfgJson = `{}`\n"},
+			problem:  "outdated",
+			repaired: map[string]string{fileEmbeddedAppGo: ""},
+		},
+		{
+			name:     "generated files",
+			check:    checkGeneratedLeftovers,
+			setup:    map[string]string{fileRecordAppGo: "package main\n", fileBuildInfoGo: "package main\n"},
+			problem:  "src/" + fileRecordAppGo + ", src/" + fileBuildInfoGo,
+			repaired: map[string]string{fileRecordAppGo: "", fileBuildInfoGo: ""},
+		},
+		{
+			name:     "extraneous import",
+			check:    checkImportsSync,
+			setup:    map[string]string{fileImportsGo: "package main\n\nimport (\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/log\"\n\t_ \"github.com/r2d2-ai/aiflow/activity/common/noop\"\n)\n"},
+			problem:  "extraneous github.com/r2d2-ai/aiflow/activity/common/noop",
+			repaired: map[string]string{fileImportsGo: repairImportsGo},
+		},
+		{
+			name:    "missing import",
+			check:   checkImportsSync,
+			setup:   map[string]string{fileImportsGo: "package main\n"},
+			problem: "missing github.com/r2d2-ai/aiflow/activity/common/log",
+		},
+		{
+			name:    "missing requirement",
+			check:   checkGoModRequires,
+			setup:   map[string]string{"go.mod": "module main\n\ngo 1.16\n"},
+			problem: "doesn't require the modules of github.com/r2d2-ai/aiflow/activity/common/log",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			tempDir, appDir := newRepairTestProject(t)
+			defer os.RemoveAll(tempDir)
+			srcDir := filepath.Join(appDir, dirSrc)
+
+			project := NewAppProject(appDir)
+
+			repair, err := test.check(project)
+			assert.Nil(t, err)
+			assert.Nil(t, repair)
+
+			// an empty content removes the file
+			for file, content := range test.setup {
+				if content == "" {
+					assert.Nil(t, os.Remove(filepath.Join(srcDir, file)))
+				} else {
+					assert.Nil(t, ioutil.WriteFile(filepath.Join(srcDir, file), []byte(content), 0644))
+				}
+			}
+
+			repair, err = test.check(project)
+			assert.Nil(t, err)
+			if !assert.NotNil(t, repair) {
+				return
+			}
+			assert.Contains(t, repair.Problem, test.problem)
+
+			if test.repaired == nil {
+				return
+			}
+			assert.Nil(t, repair.apply())
+
+			for file, content := range test.repaired {
+				data, err := ioutil.ReadFile(filepath.Join(srcDir, file))
+				if content == "" {
+					assert.True(t, os.IsNotExist(err), file)
+				} else {
+					assert.Equal(t, content, strings.TrimSpace(string(data))+"\n", file)
+				}
+			}
+
+			repair, err = test.check(project)
+			assert.Nil(t, err)
+			assert.Nil(t, repair)
+		})
+	}
+}
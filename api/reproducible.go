@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	envSourceDateEpoch = "SOURCE_DATE_EPOCH"
+)
+
+// BuildVerification is the outcome of rebuilding the app to verify that its build is reproducible
+type BuildVerification struct {
+	Executables []*VerifiedExecutable
+}
+
+// VerifiedExecutable is the sha256 of an executable before and after it was rebuilt
+type VerifiedExecutable struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (v *VerifiedExecutable) Matches() bool {
+	return v.Expected == v.Actual
+}
+
+// Reproducible checks if every rebuilt executable is identical to the executable it was compared with
+func (v *BuildVerification) Reproducible() bool {
+	for _, exe := range v.Executables {
+		if !exe.Matches() {
+			return false
+		}
+	}
+	return len(v.Executables) > 0
+}
+
+// VerifyBuild rebuilds the app reproducibly and compares the executables with those of the bin directory, built by a
+// previous reproducible build, the app is built first if the bin directory doesn't contain them
+func VerifyBuild(project common.AppProject, options common.BuildOptions) (*BuildVerification, error) {
+
+	options.Reproducible = true
+
+	expected, err := hashExecutables(expectedExecutables(project, options))
+	if err != nil {
+		return nil, err
+	}
+
+	if expected == nil {
+		logf(project, "Building the executables to compare with...\n")
+		result, err := BuildProject(project, options)
+		if err != nil {
+			return nil, err
+		}
+		expected, err = hashExecutables(result.Executables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logf(project, "Rebuilding the executables...\n")
+
+	options.Force = true
+	result, err := BuildProject(project, options)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := hashExecutables(result.Executables)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &BuildVerification{}
+	for _, exe := range expectedExecutables(project, options) {
+		if _, ok := expected[exe]; !ok {
+			continue
+		}
+		verification.Executables = append(verification.Executables, &VerifiedExecutable{Path: exe, Expected: expected[exe], Actual: actual[exe]})
+	}
+
+	return verification, nil
+}
+
+// expectedExecutables returns the executables built with the options
+func expectedExecutables(project common.AppProject, options common.BuildOptions) []string {
+
+	if len(options.Targets) == 0 {
+		return []string{project.Executable()}
+	}
+
+	var executables []string
+	for _, target := range options.Targets {
+		exe := filepath.Join(project.BinDir(), project.Name()+"-"+strings.Replace(target, "/", "-", 1))
+		if strings.HasPrefix(target, "windows/") {
+			exe = exe + ".exe"
+		}
+		executables = append(executables, exe)
+	}
+
+	return executables
+}
+
+// hashExecutables returns the sha256 of the executables that exist, nil if none exists
+func hashExecutables(executables []string) (map[string]string, error) {
+
+	var hashes map[string]string
+	for _, exe := range executables {
+		if !util.FileExists(exe) {
+			continue
+		}
+
+		hash, err := hashFile(exe)
+		if err != nil {
+			return nil, err
+		}
+
+		if hashes == nil {
+			hashes = make(map[string]string)
+		}
+		hashes[exe] = hash
+	}
+
+	return hashes, nil
+}
+
+func hashFile(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// sourceDateEpoch returns the time stamped into a reproducible build: SOURCE_DATE_EPOCH if set, otherwise the time of
+// the last commit of the project, or the Unix epoch if the project isn't in a git repository
+func sourceDateEpoch(project common.AppProject) (time.Time, error) {
+
+	if epoch := common.ProjectSession(project).Getenv(envSourceDateEpoch); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s '%s': %v", envSourceDateEpoch, epoch, err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%ct")
+	cmd.Dir = project.Dir()
+	cmd.Env = common.ProjectSession(project).Environ()
+	if out, err := cmd.Output(); err == nil {
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+	}
+
+	return time.Unix(0, 0).UTC(), nil
+}
+
+// sortGoImports sorts the imports of src/imports.go, the order of the imports determines the initialization order
+// of the contributions with older Go versions, the file is backed up with backupImports first by the build
+func sortGoImports(project common.AppProject) error {
+
+	importsFile := filepath.Join(project.SrcDir(), fileImportsGo)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, importsFile, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if onlyImports(file) {
+		// imports.go is generated, it is rewritten with the imports in a single sorted block
+		var imports []string
+		for _, spec := range file.Imports {
+			imp := spec.Path.Value
+			if spec.Name != nil {
+				imp = spec.Name.Name + " " + imp
+			}
+			imports = append(imports, imp)
+		}
+		sort.Slice(imports, func(i, j int) bool {
+			return importSortKey(imports[i]) < importSortKey(imports[j])
+		})
+
+		fmt.Fprintf(&buf, "package %s\n\nimport (\n", file.Name.Name)
+		for _, imp := range imports {
+			fmt.Fprintf(&buf, "\t%s\n", imp)
+		}
+		buf.WriteString(")\n")
+	} else {
+		ast.SortImports(fset, file)
+		if err := printer.Fprint(&buf, fset, file); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(importsFile, buf.Bytes(), 0644)
+}
+
+// onlyImports checks if the file only has import declarations and no comments
+func onlyImports(file *ast.File) bool {
+
+	if len(file.Comments) > 0 {
+		return false
+	}
+
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); !ok || genDecl.Tok != token.IMPORT {
+			return false
+		}
+	}
+
+	return true
+}
+
+// importSortKey returns the path of an import spec, 'name "path"'
+func importSortKey(imp string) string {
+	return imp[strings.Index(imp, "\""):]
+}
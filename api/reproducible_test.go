@@ -0,0 +1,80 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortGoImports(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	err = os.MkdirAll(filepath.Join(tempDir, dirSrc), os.ModePerm)
+	assert.Nil(t, err)
+
+	importsFile := filepath.Join(tempDir, dirSrc, fileImportsGo)
+	err = ioutil.WriteFile(importsFile, []byte(`package main
+
+import _ "github.com/r2d2-ai/aiflow/trigger/net/rest"
+
+import (
+	_ "github.com/r2d2-ai/aiflow/action/flow"
+	log "github.com/r2d2-ai/aiflow/activity/common/log"
+)
+`), 0644)
+	assert.Nil(t, err)
+
+	orig, err := ioutil.ReadFile(importsFile)
+	assert.Nil(t, err)
+
+	project := NewAppProject(tempDir)
+	err = backupImports(project)
+	assert.Nil(t, err)
+
+	err = sortGoImports(project)
+	assert.Nil(t, err)
+
+	buf, err := ioutil.ReadFile(importsFile)
+	assert.Nil(t, err)
+	assert.Equal(t, `package main
+
+import (
+	_ "github.com/r2d2-ai/aiflow/action/flow"
+	log "github.com/r2d2-ai/aiflow/activity/common/log"
+	_ "github.com/r2d2-ai/aiflow/trigger/net/rest"
+)
+`, string(buf))
+
+	// the sorted imports are only used for the build
+	restoreImports(project)
+	buf, err = ioutil.ReadFile(importsFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(orig), string(buf))
+	assert.False(t, util.FileExists(importsFile+".orig"))
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	defer os.Unsetenv(envSourceDateEpoch)
+
+	os.Setenv(envSourceDateEpoch, "1700000000")
+	buildTime, err := sourceDateEpoch(NewAppProject(tempDir))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), buildTime)
+
+	os.Setenv(envSourceDateEpoch, "yesterday")
+	_, err = sourceDateEpoch(NewAppProject(tempDir))
+	assert.NotNil(t, err)
+}
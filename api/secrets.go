@@ -0,0 +1,423 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	// FileSecretsEnv is the file of the project the externalized secrets are saved to
+	FileSecretsEnv = ".env"
+)
+
+// SecretFinding is a value of a descriptor that is likely a secret
+type SecretFinding struct {
+	File   string // descriptor the value was found in
+	Path   string // JSON path of the value
+	Key    string // name of the setting the value is assigned to
+	Value  string // the value, use MaskedValue to print it
+	Reason string // why the value is considered a secret
+
+	owner    string
+	property map[string]interface{} // app property holding the value, if any
+	parent   map[string]interface{}
+}
+
+// MaskedValue returns the value with all but its first characters hidden
+func (f *SecretFinding) MaskedValue() string {
+	if len(f.Value) <= 8 {
+		return strings.Repeat("*", len(f.Value))
+	}
+	return f.Value[:4] + strings.Repeat("*", len(f.Value)-4)
+}
+
+// ExternalizedSecret is a secret of AIflow.json replaced with a reference to an app property read from the environment
+type ExternalizedSecret struct {
+	Finding  *SecretFinding
+	Property string // app property, and environment variable, the value is now read from
+}
+
+var secretKeyPattern = regexp.MustCompile(`(?i)(passw(or)?d|passphrase|secret|token|api[-_]?key|access[-_]?key|private[-_]?key|client[-_]?key|credential|auth[-_]?key|signing[-_]?key|^pwd$|^auth$)`)
+
+var secretFormats = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`), "AWS access key"},
+	{regexp.MustCompile(`^gh[pousr]_[0-9A-Za-z]{36,}$`), "GitHub token"},
+	{regexp.MustCompile(`^github_pat_[0-9A-Za-z_]{22,}$`), "GitHub token"},
+	{regexp.MustCompile(`^glpat-[0-9A-Za-z_-]{20,}$`), "GitLab token"},
+	{regexp.MustCompile(`^xox[abposr]-[0-9A-Za-z-]{10,}$`), "Slack token"},
+	{regexp.MustCompile(`^(sk|rk)_(live|test)_[0-9A-Za-z]{16,}$`), "Stripe key"},
+	{regexp.MustCompile(`^AIza[0-9A-Za-z_-]{35}$`), "Google API key"},
+	{regexp.MustCompile(`^sk-[0-9A-Za-z_-]{20,}$`), "API secret key"},
+	{regexp.MustCompile(`^eyJ[0-9A-Za-z_-]+\.eyJ[0-9A-Za-z_-]+\.[0-9A-Za-z_-]*$`), "JSON web token"},
+	{regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY-----`), "private key"},
+	{regexp.MustCompile(`^[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`), "URL with credentials"},
+}
+
+// keys whose values are identifiers rather than settings
+var nonSecretKeys = map[string]bool{"ref": true, "id": true, "name": true, "type": true, "description": true, "version": true, "appModel": true, "imports": true, "flowURI": true}
+
+// ScanSecrets returns the values of AIflow.json and engine.json that are likely secrets
+func ScanSecrets(project common.AppProject) ([]*SecretFinding, error) {
+
+	var findings []*SecretFinding
+	for _, file := range []string{fileAIflowJson, fileEngineJson} {
+		path := filepath.Join(project.Dir(), file)
+		if file == fileEngineJson && !util.FileExists(path) {
+			continue
+		}
+
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileFindings, err := scanDescriptorSecrets(file, buf)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, fileFindings...)
+	}
+
+	return findings, nil
+}
+
+// scanDescriptorSecrets returns the values of the descriptor that are likely secrets
+func scanDescriptorSecrets(file string, buf []byte) ([]*SecretFinding, error) {
+
+	if len(strings.TrimSpace(string(buf))) == 0 {
+		return nil, nil
+	}
+
+	var descriptor interface{}
+	err := json.Unmarshal(buf, &descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", file, err)
+	}
+
+	var findings []*SecretFinding
+	walkSecrets(descriptor, "", "", func(finding *SecretFinding) {
+		finding.File = file
+		findings = append(findings, finding)
+	})
+
+	return findings, nil
+}
+
+func walkSecrets(val interface{}, path, owner string, visit func(*SecretFinding)) {
+
+	switch t := val.(type) {
+	case map[string]interface{}:
+		if id, ok := t["id"].(string); ok && id != "" {
+			owner = id
+		}
+
+		// app properties and attributes are objects with a name and a value
+		if name, ok := t["name"].(string); ok {
+			if value, ok := t["value"].(string); ok {
+				if reason := secretReason(name, value); reason != "" {
+					visit(&SecretFinding{Path: path + ".value", Key: name, Value: value, Reason: reason, owner: owner, property: t})
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if nonSecretKeys[key] {
+				continue
+			}
+
+			keyPath := path + "." + key
+			if value, ok := t[key].(string); ok {
+				if _, isNamed := t["name"].(string); isNamed && key == "value" {
+					// checked with the name of the object
+					continue
+				}
+				if reason := secretReason(key, value); reason != "" {
+					visit(&SecretFinding{Path: keyPath, Key: key, Value: value, Reason: reason, owner: owner, parent: t})
+				}
+				continue
+			}
+			walkSecrets(t[key], keyPath, owner, visit)
+		}
+	case []interface{}:
+		for i, item := range t {
+			walkSecrets(item, path+"["+strconv.Itoa(i)+"]", owner, visit)
+		}
+	}
+}
+
+// secretReason returns why the value of the key is likely a secret, or an empty string if it isn't
+func secretReason(key, value string) string {
+
+	value = strings.TrimSpace(value)
+	if value == "" || isValueReference(value) {
+		return ""
+	}
+
+	for _, format := range secretFormats {
+		if format.pattern.MatchString(value) {
+			return format.reason
+		}
+	}
+
+	if secretKeyPattern.MatchString(key) {
+		return "secret setting name"
+	}
+
+	if isHighEntropy(value) {
+		return "high entropy value"
+	}
+
+	return ""
+}
+
+// isValueReference checks if the value is resolved at runtime (a mapping expression, property or environment reference)
+func isValueReference(value string) bool {
+	return strings.HasPrefix(value, "=") || strings.HasPrefix(value, "$")
+}
+
+// isHighEntropy checks if the value looks like a random key or token
+func isHighEntropy(value string) bool {
+
+	if len(value) < 20 || strings.ContainsAny(value, " ./:\\") {
+		return false
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			hasLetter = true
+		case c == '-' || c == '_' || c == '+' || c == '=':
+		default:
+			return false
+		}
+	}
+
+	return hasLetter && hasDigit && shannonEntropy(value) >= 4.0
+}
+
+func shannonEntropy(value string) float64 {
+
+	counts := make(map[rune]int)
+	for _, c := range value {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	length := float64(len(value))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// ExternalizeSecrets replaces the secrets of AIflow.json with references to app properties whose values are read from
+// the environment variables of the same name, the secrets found in engine.json are returned as not externalized
+func ExternalizeSecrets(project common.AppProject, dryRun bool) ([]*ExternalizedSecret, []*SecretFinding, error) {
+
+	appObj, err := readAppDescriptorMap(project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// scan the map that is written back so the findings refer to its objects
+	var findings []*SecretFinding
+	walkSecrets(appObj, "", "", func(finding *SecretFinding) {
+		finding.File = fileAIflowJson
+		findings = append(findings, finding)
+	})
+
+	var notExternalized []*SecretFinding
+	engineJsonPath := filepath.Join(project.Dir(), fileEngineJson)
+	if util.FileExists(engineJsonPath) {
+		buf, err := ioutil.ReadFile(engineJsonPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		notExternalized, err = scanDescriptorSecrets(fileEngineJson, buf)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(findings) == 0 {
+		return nil, notExternalized, nil
+	}
+
+	used := make(map[string]bool)
+	for _, property := range getObjects(appObj, "properties") {
+		if name, ok := property["name"].(string); ok {
+			used[name] = true
+		}
+	}
+
+	var externalized []*ExternalizedSecret
+	for _, finding := range findings {
+
+		if finding.property != nil {
+			// the secret is the value of an app property, it is read from the environment variable of the property
+			name, _ := finding.property["name"].(string)
+			if !isEnvVarName(name) {
+				notExternalized = append(notExternalized, finding)
+				continue
+			}
+			finding.property["value"] = ""
+			externalized = append(externalized, &ExternalizedSecret{Finding: finding, Property: name})
+			continue
+		}
+
+		name := secretPropertyName(finding.owner, finding.Key, used)
+		used[name] = true
+
+		finding.parent[finding.Key] = "=$property[" + name + "]"
+		properties, _ := appObj["properties"].([]interface{})
+		appObj["properties"] = append(properties, map[string]interface{}{"name": name, "type": "string", "value": ""})
+
+		externalized = append(externalized, &ExternalizedSecret{Finding: finding, Property: name})
+	}
+
+	if dryRun || len(externalized) == 0 {
+		return externalized, notExternalized, nil
+	}
+
+	// the values are saved before they are removed from the descriptor
+	err = saveSecretsEnvFile(project, externalized)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to save the secrets to %s: %v", FileSecretsEnv, err)
+	}
+
+	return externalized, notExternalized, writeAppDescriptorMap(project, appObj)
+}
+
+// saveSecretsEnvFile sets the environment variables of the externalized secrets in the .env file of the project,
+// the other variables of the file are kept, and makes sure git ignores the file
+func saveSecretsEnvFile(project common.AppProject, secrets []*ExternalizedSecret) error {
+
+	envPath := filepath.Join(project.Dir(), FileSecretsEnv)
+
+	var lines []string
+	if buf, err := ioutil.ReadFile(envPath); err == nil {
+		lines = strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, secret := range secrets {
+		assignment := secret.Property + "=" + shellQuote(secret.Finding.Value)
+
+		replaced := false
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimPrefix(strings.TrimSpace(line), "export "), secret.Property+"=") {
+				lines[i] = assignment
+				replaced = true
+			}
+		}
+		if !replaced {
+			lines = append(lines, assignment)
+		}
+	}
+
+	err := ensureGitIgnored(project.Dir(), FileSecretsEnv)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(envPath, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+// ensureGitIgnored adds the file to the .gitignore of the directory unless it is already listed
+func ensureGitIgnored(dir, name string) error {
+
+	gitignorePath := filepath.Join(dir, ".gitignore")
+
+	content, err := ioutil.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == name || line == "/"+name {
+			return nil
+		}
+	}
+
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte("/"+name+"\n")...)
+
+	return ioutil.WriteFile(gitignorePath, content, 0644)
+}
+
+// shellQuote quotes the value so that it is read unchanged by a shell and by the .env loaders
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+var nonEnvVarChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+var camelCaseBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// secretPropertyName returns an unused property name, usable as an environment variable, for a secret setting
+func secretPropertyName(owner, key string, used map[string]bool) string {
+
+	name := camelCaseBoundary.ReplaceAllString(key, "${1}_${2}")
+	if owner != "" {
+		name = camelCaseBoundary.ReplaceAllString(owner, "${1}_${2}") + "_" + name
+	}
+	name = strings.Trim(nonEnvVarChars.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+	if !isEnvVarName(name) {
+		name = strings.TrimSuffix("SECRET_"+name, "_")
+	}
+
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+
+	return unique
+}
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isEnvVarName(name string) bool {
+	return envVarName.MatchString(name)
+}
+
+// warnEmbeddedSecrets warns about the likely secrets of a descriptor that is embedded in the executable
+func warnEmbeddedSecrets(project common.AppProject, file, content string) {
+
+	findings, err := scanDescriptorSecrets(file, []byte(content))
+	if err != nil || len(findings) == 0 {
+		return
+	}
+
+	eprintf(project, "Warning: embedding %d likely secret(s) of %s in the executable, use 'AIflow secrets externalize' to read them from the environment\n", len(findings), file)
+	for _, finding := range findings {
+		eprintf(project, "  %s: %s (%s)\n", finding.Path, finding.MaskedValue(), finding.Reason)
+	}
+}
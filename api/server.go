@@ -0,0 +1,587 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+)
+
+const (
+	maxServerBuilds = 100
+)
+
+// ServerOptions configures the HTTP API served by 'AIflow serve'
+type ServerOptions struct {
+	Token          string   // bearer token required by the requests, none if empty
+	AllowedOrigins []string // origins of the browsers allowed to call the API
+	AllowedHosts   []string // host names the requests may be sent to besides the loopback ones
+}
+
+// Server exposes the operations on projects as an HTTP+JSON API, the operations modifying a project are serialized
+// per project and a request for a project that is busy is rejected
+type Server struct {
+	options ServerOptions
+	mux     *http.ServeMux
+
+	mu     sync.Mutex
+	locks  map[string]bool // directories of the projects with an operation in progress
+	builds map[string]*serverBuild
+	order  []string // ids of the builds, oldest first
+	nextID int
+}
+
+// serverBuild is a build started through the API, its events are streamed with server-sent events
+type serverBuild struct {
+	ID     string              `json:"id"`
+	Dir    string              `json:"dir"`
+	Status string              `json:"status"` // running, succeeded or failed
+	Result *common.BuildResult `json:"result,omitempty"`
+	Error  string              `json:"error,omitempty"`
+
+	mu      sync.Mutex
+	events  []serverEvent
+	changed chan struct{} // closed when an event is added
+	partial string        // log output not terminated by a newline yet
+}
+
+type serverEvent struct {
+	name string
+	data interface{}
+}
+
+// NewServer returns the HTTP handler of the API
+func NewServer(options ServerOptions) *Server {
+
+	s := &Server{options: options, mux: http.NewServeMux(), locks: make(map[string]bool), builds: make(map[string]*serverBuild)}
+
+	s.mux.HandleFunc("/v1/projects", s.post(s.handleCreate))
+	s.mux.HandleFunc("/v1/contribs", s.handleContribs)
+	s.mux.HandleFunc("/v1/contribs/install", s.post(s.handleInstall))
+	s.mux.HandleFunc("/v1/contribs/uninstall", s.post(s.handleUninstall))
+	s.mux.HandleFunc("/v1/validate", s.post(s.handleValidate))
+	s.mux.HandleFunc("/v1/imports/sync", s.post(s.handleImportsSync))
+	s.mux.HandleFunc("/v1/imports/resolve", s.post(s.handleImportsResolve))
+	s.mux.HandleFunc("/v1/builds", s.post(s.handleBuild))
+	s.mux.HandleFunc("/v1/builds/", s.handleBuildStatus)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	// the pages of a site whose name is rebound to the loopback address could otherwise call the API
+	if !s.allowedHost(r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("host '%s' isn't allowed", r.Host))
+		return
+	}
+
+	// browsers are only allowed from the configured origins, other pages could otherwise drive the API of the machine
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !s.allowedOrigin(origin) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin '%s' isn't allowed", origin))
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	if s.options.Token != "" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the bearer token of the request, EventSource can't set headers so the events of a build accept
+// the token as a query parameter as well, the other requests don't to keep it out of logs and browser histories
+func (s *Server) authorized(r *http.Request) bool {
+
+	token := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	} else if isBuildEventsPath(r.URL.Path) {
+		token = r.URL.Query().Get("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.options.Token)) == 1
+}
+
+// isBuildEventsPath returns whether the path is the one of the events of a build, /v1/builds/{id}/events
+func isBuildEventsPath(path string) bool {
+	id := strings.TrimSuffix(strings.TrimPrefix(path, "/v1/builds/"), "/events")
+	return path == "/v1/builds/"+id+"/events" && id != "" && !strings.Contains(id, "/")
+}
+
+// allowedHost checks that the host of the request is a loopback one or an allowed host name
+func (s *Server) allowedHost(hostPort string) bool {
+
+	host := hostPort
+	if h, _, err := net.SplitHostPort(hostPort); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, allowed := range s.options.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) allowedOrigin(origin string) bool {
+	for _, allowed := range s.options.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// post only accepts JSON POST requests
+func (s *Server) post(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires POST", r.URL.Path))
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("the request body must be application/json"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+type projectRequest struct {
+	Dir string `json:"dir"`
+}
+
+type createRequest struct {
+	BasePath    string `json:"basePath"`
+	Name        string `json:"name"`
+	AppFile     string `json:"appFile"`
+	CoreVersion string `json:"coreVersion"`
+}
+
+type contribsRequest struct {
+	Dir      string   `json:"dir"`
+	Contribs []string `json:"contribs"`
+}
+
+type validateRequest struct {
+	Dir string `json:"dir"`
+	Env string `json:"env"`
+}
+
+type buildRequest struct {
+	Dir     string              `json:"dir"`
+	Options common.BuildOptions `json:"options"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+
+	var req createRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+	if req.BasePath == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("basePath is required"))
+		return
+	}
+
+	project, err := CreateProjectInSession(common.NewSession(), req.BasePath, req.Name, req.AppFile, req.CoreVersion)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"dir": project.Dir()})
+}
+
+func (s *Server) handleContribs(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires GET", r.URL.Path))
+		return
+	}
+
+	project, ok := openProject(w, r.URL.Query().Get("dir"))
+	if !ok {
+		return
+	}
+
+	specs, err := GetContribSpecs(project, r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if specs == nil {
+		specs = []*ContribSpec{}
+	}
+
+	writeJSON(w, http.StatusOK, specs)
+}
+
+func (s *Server) handleInstall(w http.ResponseWriter, r *http.Request) {
+	s.handleContribsChange(w, r, InstallPackage)
+}
+
+func (s *Server) handleUninstall(w http.ResponseWriter, r *http.Request) {
+	s.handleContribsChange(w, r, UninstallPackage)
+}
+
+func (s *Server) handleContribsChange(w http.ResponseWriter, r *http.Request, change func(project common.AppProject, pkg string) error) {
+
+	var req contribsRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+	if len(req.Contribs) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("contribs is required"))
+		return
+	}
+
+	project, unlock, ok := s.lockProject(w, req.Dir)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	for _, contrib := range req.Contribs {
+		if err := change(project, contrib); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s: %v", contrib, err))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{"contribs": req.Contribs})
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+
+	var req validateRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+	if req.Dir == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("dir is required"))
+		return
+	}
+
+	dir, err := filepath.Abs(req.Dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// an invalid project is the result of the validation, not an error of the request
+	response := map[string]interface{}{"valid": true}
+	if err := ValidateProject(NewAppProjectInSession(common.NewSession(), dir), req.Env); err != nil {
+		response["valid"] = false
+		response["error"] = err.Error()
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleImportsSync(w http.ResponseWriter, r *http.Request) {
+	s.handleProjectOperation(w, r, SyncProjectImports)
+}
+
+func (s *Server) handleImportsResolve(w http.ResponseWriter, r *http.Request) {
+	s.handleProjectOperation(w, r, ResolveProjectImports)
+}
+
+func (s *Server) handleProjectOperation(w http.ResponseWriter, r *http.Request, operation func(project common.AppProject) error) {
+
+	var req projectRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+
+	project, unlock, ok := s.lockProject(w, req.Dir)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	if err := operation(project); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"dir": project.Dir()})
+}
+
+// handleBuild starts a build of the project, the project stays locked until the build is done
+func (s *Server) handleBuild(w http.ResponseWriter, r *http.Request) {
+
+	var req buildRequest
+	if !readRequest(w, r, &req) {
+		return
+	}
+
+	project, unlock, ok := s.lockProject(w, req.Dir)
+	if !ok {
+		return
+	}
+
+	build := &serverBuild{Dir: project.Dir(), Status: "running", changed: make(chan struct{})}
+
+	// the logs and warnings of the session of the build and the output of the commands it runs are its events
+	session := common.NewSession()
+	session.SetVerbose(true)
+	session.SetOutput(build)
+	session.SetErrOutput(build)
+	project = NewAppProjectInSession(session, project.Dir())
+
+	s.mu.Lock()
+	s.nextID++
+	build.ID = strconv.Itoa(s.nextID)
+	s.builds[build.ID] = build
+	s.order = append(s.order, build.ID)
+	s.pruneBuilds()
+	s.mu.Unlock()
+
+	go func() {
+		defer unlock()
+
+		result, err := BuildProject(project, req.Options)
+		build.finish(result, err)
+	}()
+
+	writeJSON(w, http.StatusAccepted, build.status())
+}
+
+// handleBuildStatus returns the status of a build, /v1/builds/{id}, or streams its events, /v1/builds/{id}/events
+func (s *Server) handleBuildStatus(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires GET", r.URL.Path))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/builds/")
+	id := strings.TrimSuffix(path, "/events")
+
+	s.mu.Lock()
+	build, found := s.builds[id]
+	s.mu.Unlock()
+
+	if !found || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("build '%s' not found", id))
+		return
+	}
+
+	if path == id {
+		writeJSON(w, http.StatusOK, build.status())
+		return
+	}
+
+	build.streamEvents(w, r)
+}
+
+// pruneBuilds forgets the oldest builds that are done, the lock of the server must be held
+func (s *Server) pruneBuilds() {
+
+	for i := 0; len(s.builds) > maxServerBuilds && i < len(s.order); {
+		id := s.order[i]
+		if s.builds[id].status().Status == "running" {
+			i++
+			continue
+		}
+		delete(s.builds, id)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+}
+
+// lockProject locks the valid project of the directory, the request is rejected if the project is busy
+func (s *Server) lockProject(w http.ResponseWriter, dir string) (common.AppProject, func(), bool) {
+
+	project, ok := openProject(w, dir)
+	if !ok {
+		return nil, nil, false
+	}
+	dir = project.Dir()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.locks[dir] {
+		writeError(w, http.StatusConflict, fmt.Errorf("project '%s' is busy", dir))
+		return nil, nil, false
+	}
+	s.locks[dir] = true
+
+	unlock := func() {
+		s.mu.Lock()
+		delete(s.locks, dir)
+		s.mu.Unlock()
+	}
+
+	return project, unlock, true
+}
+
+// openProject returns the valid project of the directory bound to a new session, an error response is written if the
+// project is invalid
+func openProject(w http.ResponseWriter, dir string) (common.AppProject, bool) {
+
+	if dir == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("dir is required"))
+		return nil, false
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	project := NewAppProjectInSession(common.NewSession(), dir)
+	if err := project.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+
+	return project, true
+}
+
+// Write adds the lines logged by the session of the build as log events
+func (b *serverBuild) Write(p []byte) (int, error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := strings.Split(b.partial+string(p), "\n")
+	b.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		b.addEvent("log", map[string]string{"message": line})
+	}
+
+	return len(p), nil
+}
+
+func (b *serverBuild) finish(result *common.BuildResult, err error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.partial != "" {
+		b.addEvent("log", map[string]string{"message": b.partial})
+		b.partial = ""
+	}
+
+	if err != nil {
+		b.Status = "failed"
+		b.Error = err.Error()
+		b.addEvent("error", map[string]string{"error": b.Error})
+	} else {
+		b.Status = "succeeded"
+		b.Result = result
+		b.addEvent("result", result)
+	}
+}
+
+// addEvent adds the event and wakes up the streams, the lock of the build must be held
+func (b *serverBuild) addEvent(name string, data interface{}) {
+	b.events = append(b.events, serverEvent{name: name, data: data})
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *serverBuild) status() *serverBuild {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &serverBuild{ID: b.ID, Dir: b.Dir, Status: b.Status, Result: b.Result, Error: b.Error}
+}
+
+// streamEvents sends the events of the build as server-sent events, from the first one, until the build is done or
+// the client disconnects
+func (b *serverBuild) streamEvents(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming isn't supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for sent := 0; ; {
+		b.mu.Lock()
+		events := b.events[sent:]
+		done := b.Status != "running"
+		changed := b.changed
+		b.mu.Unlock()
+
+		for _, event := range events {
+			data, _ := json.Marshal(event.data)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", sent, event.name, data)
+			sent++
+		}
+		flusher.Flush()
+
+		if done {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprintf(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// readRequest decodes the JSON body of the request, an error response is written if it is invalid
+func readRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
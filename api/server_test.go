@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func serverRequest(handler http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Host = "localhost:7450"
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		if name == "Host" {
+			r.Host = value
+			continue
+		}
+		r.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServerRequests(t *testing.T) {
+
+	server := NewServer(ServerOptions{Token: "secret", AllowedOrigins: []string{"http://localhost:3000"}})
+	auth := map[string]string{"Authorization": "Bearer secret"}
+
+	w := serverRequest(server, http.MethodPost, "/v1/validate", `{"dir": "/missing"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serverRequest(server, http.MethodPost, "/v1/validate", `{"dir": "/missing"}`, map[string]string{"Authorization": "Bearer secret", "Origin": "http://evil.example"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serverRequest(server, http.MethodGet, "/v1/validate", "", auth)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serverRequest(server, http.MethodPost, "/v1/validate", `{"dir": "/missing", "unknown": true}`, auth)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serverRequest(server, http.MethodPost, "/v1/validate", `{"dir": "/missing"}`, map[string]string{"Authorization": "Bearer secret", "Origin": "http://localhost:3000"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+
+	var response map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, false, response["valid"])
+	assert.Contains(t, response["error"], "missing AIflow.json")
+
+	// the token is only accepted as a query parameter by the events of a build
+	w = serverRequest(server, http.MethodGet, "/v1/builds/42/events?token=secret", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serverRequest(server, http.MethodGet, "/v1/builds/42?token=secret", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serverRequest(server, http.MethodGet, "/v1/builds/42/events?token=wrong", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// only the loopback hosts are allowed, a rebound DNS name isn't
+	for host, status := range map[string]int{"127.0.0.1:7450": http.StatusNotFound, "[::1]:7450": http.StatusNotFound, "evil.example:7450": http.StatusForbidden} {
+		w = serverRequest(server, http.MethodGet, "/v1/builds/42", "", map[string]string{"Host": host, "Authorization": "Bearer secret"})
+		assert.Equal(t, status, w.Code, host)
+	}
+
+	server = NewServer(ServerOptions{AllowedHosts: []string{"devbox"}})
+	w = serverRequest(server, http.MethodGet, "/v1/builds/42", "", map[string]string{"Host": "devbox:7450"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServerProjectLock(t *testing.T) {
+
+	tempDir := newWorkspaceTestDir(t, "app")
+	defer os.RemoveAll(tempDir)
+
+	server := NewServer(ServerOptions{})
+	rec := httptest.NewRecorder()
+
+	project, unlock, ok := server.lockProject(rec, tempDir+"/app")
+	assert.True(t, ok)
+
+	w := serverRequest(server, http.MethodPost, "/v1/imports/sync", `{"dir": "`+project.Dir()+`"}`, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	unlock()
+	_, unlock, ok = server.lockProject(rec, project.Dir())
+	assert.True(t, ok)
+	unlock()
+}
+
+func TestServerBuildEvents(t *testing.T) {
+
+	server := NewServer(ServerOptions{})
+	build := &serverBuild{ID: "1", Dir: "/app", Status: "running", changed: make(chan struct{})}
+	server.builds[build.ID] = build
+
+	_, _ = build.Write([]byte("Performing 'go build'...\nBuil"))
+	_, _ = build.Write([]byte("t\n"))
+	build.finish(&common.BuildResult{Executables: []string{"/app/bin/app"}}, nil)
+
+	w := serverRequest(server, http.MethodGet, "/v1/builds/1/events", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 0\nevent: log\ndata: {\"message\":\"Performing 'go build'...\"}\n\n"+
+		"id: 1\nevent: log\ndata: {\"message\":\"Built\"}\n\n", w.Body.String()[:strings.Index(w.Body.String(), "id: 2")])
+	assert.Contains(t, w.Body.String(), "event: result\ndata: {\"executables\":[\"/app/bin/app\"]")
+
+	w = serverRequest(server, http.MethodGet, "/v1/builds/1", "", nil)
+	var status map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "succeeded", status["status"])
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectSession(t *testing.T) {
+
+	var out bytes.Buffer
+	session := common.NewSession()
+	session.SetOutput(&out)
+	session.SetVerbose(true)
+	session.Setenv("GOOS", "windows")
+	session.Setenv("GOARCH", "arm64")
+
+	project := NewAppProjectInSession(session, filepath.Join("apps", "myapp"))
+	assert.Equal(t, session, common.ProjectSession(project))
+	assert.Equal(t, filepath.Join("apps", "myapp", dirBin, "myapp.exe"), project.Executable())
+	assert.Equal(t, []string{"windows/arm64"}, buildTargets(project, common.BuildOptions{}))
+
+	logf(project, "Building %s\n", project.Name())
+	assert.Equal(t, "Building myapp\n", out.String())
+
+	// the messages and the commands of the API use the output and environment of the session
+	var errOut bytes.Buffer
+	session.SetErrOutput(&errOut)
+	eprintf(project, "Warning: %s\n", "unused import")
+	assert.Equal(t, "Warning: unused import\n", errOut.String())
+
+	out.Reset()
+	assert.Nil(t, execCmd(project, exec.Command("go", "env", "GOOS"), os.TempDir()))
+	assert.Equal(t, "windows\n", out.String())
+
+	defaultProject := NewAppProject(filepath.Join("apps", "myapp"))
+	assert.Equal(t, common.DefaultSession(), common.ProjectSession(defaultProject))
+}
@@ -32,37 +32,44 @@ type ShimBuilder struct {
 }
 
 func (sb *ShimBuilder) Build(project common.AppProject) error {
+	_, err := sb.BuildExecutables(project)
+	return err
+}
+
+// BuildExecutables builds the app with the shim and returns the executable built with go build, the output of the
+// build.go or Makefile of a shim isn't known so none is returned for them
+func (sb *ShimBuilder) BuildExecutables(project common.AppProject) ([]string, error) {
 
 	err := backupMain(project)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer shimCleanup(project)
 
 	err = createShimSupportGoFile(project)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if Verbose() {
-		fmt.Println("Preparing shim...")
-	}
+	logf(project, "Preparing shim...\n")
 	built, err := prepareShim(project, sb.shim)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !built {
-		fmt.Println("Using go build to build shim...")
+	if built {
+		return nil, nil
+	}
 
-		err := simpleGoBuild(project)
-		if err != nil {
-			return err
-		}
+	printf(project, "Using go build to build shim...\n")
+
+	exe, err := simpleGoBuild(project, false)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return []string{exe}, nil
 }
 
 func prepareShim(project common.AppProject, shim string) (bool, error) {
@@ -79,7 +86,7 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 		return false, err
 	}
 
-	err = registerImports(project, descriptor)
+	aliases, err := registerImports(project, descriptor)
 	if err != nil {
 		return false, err
 	}
@@ -91,7 +98,7 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 
 			if trgCfg.Ref != "" {
 				found := false
-				ref, found = GetAliasRef("AIflow:trigger", trgCfg.Ref)
+				ref, found = aliases.getRef("AIflow:trigger", trgCfg.Ref)
 				if !found {
 					return false, fmt.Errorf("unable to determine ref for trigger: %s", trgCfg.Id)
 				}
@@ -125,7 +132,7 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 				makefilePath := filepath.Join(shimFilePath, dirShim, fileMakefile)
 
 				if _, err := os.Stat(goBuildFilePath); err == nil {
-					fmt.Println("Using build.go to build shim......")
+					printf(project, "Using build.go to build shim......\n")
 
 					err = util.CopyFile(goBuildFilePath, filepath.Join(project.SrcDir(), fileBuildGo))
 					if err != nil {
@@ -133,7 +140,7 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 					}
 
 					// Execute go run gobuild.go
-					err = util.ExecCmd(exec.Command("go", "run", fileBuildGo), project.SrcDir())
+					err = execCmd(project, exec.Command("go", "run", fileBuildGo), project.SrcDir())
 					if err != nil {
 						return false, err
 					}
@@ -141,24 +148,20 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 					return true, nil
 				} else if _, err := os.Stat(makefilePath); err == nil {
 					//look for Makefile and execute it
-					fmt.Println("Using make file to build shim...")
+					printf(project, "Using make file to build shim...\n")
 
 					err = util.CopyFile(makefilePath, filepath.Join(project.SrcDir(), fileMakefile))
 					if err != nil {
 						return false, err
 					}
 
-					if Verbose() {
-						fmt.Println("Make File:", makefilePath)
-					}
+					logf(project, "Make File: %s\n", makefilePath)
 
 					// Execute make
 					cmd := exec.Command("make", "-C", project.SrcDir())
-					cmd.Stdout = os.Stdout
-					cmd.Stderr = os.Stderr
-					cmd.Env = util.ReplaceEnvValue(os.Environ(), "GOPATH", project.Dir())
+					cmd.Env = util.ReplaceEnvValue(common.ProjectSession(project).Environ(), "GOPATH", project.Dir())
 
-					err = cmd.Run()
+					err = execCmd(project, cmd, "")
 					if err != nil {
 						return false, err
 					}
@@ -178,21 +181,19 @@ func prepareShim(project common.AppProject, shim string) (bool, error) {
 
 func shimCleanup(project common.AppProject) {
 
-	if Verbose() {
-		fmt.Println("Cleaning up shim support files...")
-	}
+	logf(project, "Cleaning up shim support files...\n")
 
 	err := util.DeleteFile(filepath.Join(project.SrcDir(), fileShimSupportGo))
 	if err != nil {
-		fmt.Printf("Unable to delete: %s", fileShimSupportGo)
+		eprintf(project, "Unable to delete: %s\n", fileShimSupportGo)
 	}
 	err = util.DeleteFile(filepath.Join(project.SrcDir(), fileShimGo))
 	if err != nil {
-		fmt.Printf("Unable to delete: %s", fileShimGo)
+		eprintf(project, "Unable to delete: %s\n", fileShimGo)
 	}
 	err = util.DeleteFile(filepath.Join(project.SrcDir(), fileBuildGo))
 	if err != nil {
-		fmt.Printf("Unable to delete: %s", fileBuildGo)
+		eprintf(project, "Unable to delete: %s\n", fileBuildGo)
 	}
 }
 
@@ -200,9 +201,7 @@ func createShimSupportGoFile(project common.AppProject) error {
 
 	shimSrcPath := filepath.Join(project.SrcDir(), fileShimSupportGo)
 
-	if Verbose() {
-		fmt.Println("Creating shim support files...")
-	}
+	logf(project, "Creating shim support files...\n")
 
 	flowCoreImport, err := util.NewAIflowImportFromPath(AIflowCoreRepo)
 	if err != nil {
@@ -227,19 +226,41 @@ func createShimSupportGoFile(project common.AppProject) error {
 	return nil
 }
 
-func registerImports(project common.AppProject, appDesc *util.AIflowAppDescriptor) error {
+// importAliases are the refs of the import aliases of an app by contribution type, they are registered for a single
+// build so that the aliases of other apps don't interfere
+type importAliases map[string]map[string]string
+
+func (a importAliases) register(contribType string, alias, ref string) {
+	if a[contribType] == nil {
+		a[contribType] = make(map[string]string)
+	}
+	a[contribType][alias] = ref
+}
+
+func (a importAliases) getRef(contribType string, alias string) (string, bool) {
+	if alias == "" {
+		return "", false
+	}
+	ref, found := a[contribType][strings.TrimPrefix(alias, "#")]
+	return ref, found
+}
+
+// registerImports returns the aliases of the imports of the app, an alias used by more than one import is reported
+// by the import conflicts and the last import using it wins
+func registerImports(project common.AppProject, appDesc *util.AIflowAppDescriptor) (importAliases, error) {
 
+	aliases := make(importAliases)
 	for _, anImport := range appDesc.Imports {
-		err := registerImport(project, anImport)
+		err := registerImport(project, aliases, anImport)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return aliases, nil
 }
 
-func registerImport(project common.AppProject, anImport string) error {
+func registerImport(project common.AppProject, aliases importAliases, anImport string) error {
 
 	matches := flowImportPattern.FindStringSubmatch(anImport)
 
@@ -269,42 +290,18 @@ func registerImport(project common.AppProject, anImport string) error {
 	}
 
 	if ct != "" {
-		RegisterAlias(ct, alias, ref)
+		aliases.register(ct, alias, ref)
 	}
 
 	return nil
 }
 
-var aliases = make(map[string]map[string]string)
-
+// RegisterAlias registers the ref of an import alias in the default session
 func RegisterAlias(contribType string, alias, ref string) {
-
-	aliasToRefMap, exists := aliases[contribType]
-	if !exists {
-		aliasToRefMap = make(map[string]string)
-		aliases[contribType] = aliasToRefMap
-	}
-
-	aliasToRefMap[alias] = ref
+	common.DefaultSession().RegisterAlias(contribType, alias, ref)
 }
 
+// GetAliasRef returns the ref of an import alias registered in the default session
 func GetAliasRef(contribType string, alias string) (string, bool) {
-	if alias == "" {
-		return "", false
-	}
-
-	if alias[0] == '#' {
-		alias = alias[1:]
-	}
-	aliasToRefMap, exists := aliases[contribType]
-	if !exists {
-		return "", false
-	}
-
-	ref, exists := aliasToRefMap[alias]
-	if !exists {
-		return "", false
-	}
-
-	return ref, true
+	return common.DefaultSession().GetAliasRef(contribType, alias)
 }
@@ -1,19 +1,24 @@
 package api
 
 import (
-	"fmt"
 	"os/exec"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
-	"github.com/r2d2-ai/aiflow-cli/util"
 )
 
 func UpdatePkg(project common.AppProject, pkg string) error {
 
-	if Verbose() {
-		fmt.Printf("Updating Package: %s \n", pkg)
+	logf(project, "Updating Package: %s \n", pkg)
+
+	err := fireEvent(&common.UpdateEvent{Phase: common.PhasePre, Project: project, Pkg: pkg})
+	if err != nil {
+		return err
+	}
+
+	err = execCmd(project, exec.Command("go", "get", "-u", pkg), project.SrcDir())
+	if err != nil {
+		return err
 	}
 
-	err := util.ExecCmd(exec.Command("go", "get", "-u", pkg), project.SrcDir())
-	return err
+	return fireEvent(&common.UpdateEvent{Phase: common.PhasePost, Project: project, Pkg: pkg})
 }
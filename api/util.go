@@ -1,11 +1,15 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
 	"github.com/r2d2-ai/aiflow/app"
@@ -50,6 +54,15 @@ func writeAppDescriptor(project common.AppProject, appDescriptor *app.Config) er
 	return nil
 }
 
+// fireEvent passes the event to the registered hooks, an error from a hook vetoes the operation
+func fireEvent(event common.Event) error {
+	err := common.FireEvent(event)
+	if err != nil {
+		return fmt.Errorf("%s %s hook failed: %v", event.EventName(), event.EventPhase(), err)
+	}
+	return nil
+}
+
 func backupMain(project common.AppProject) error {
 	mainGo := filepath.Join(project.SrcDir(), fileMainGo)
 	mainGoBak := filepath.Join(project.SrcDir(), fileMainGo+".bak")
@@ -59,17 +72,13 @@ func backupMain(project common.AppProject) error {
 		if _, err := os.Stat(mainGoBak); err == nil {
 
 			//remove old main backup
-			if Verbose() {
-				fmt.Printf("Removing old main backup: %s\n", mainGoBak)
-			}
+			logf(project, "Removing old main backup: %s\n", mainGoBak)
 			err = os.Rename(mainGoBak, mainGo)
 			if err != nil {
 				return err
 			}
 		}
-		if Verbose() {
-			fmt.Println("Backing up main.go")
-		}
+		logf(project, "Backing up main.go\n")
 		err = os.Rename(mainGo, mainGoBak)
 		if err != nil {
 			return err
@@ -87,9 +96,7 @@ func restoreMain(project common.AppProject) error {
 	if _, err := os.Stat(mainGo); err != nil {
 		//main not found, check for backup main
 		if _, err := os.Stat(mainGoBak); err == nil {
-			if Verbose() {
-				fmt.Printf("Restoring main from: %s\n", mainGoBak)
-			}
+			logf(project, "Restoring main from: %s\n", mainGoBak)
 			err = os.Rename(mainGoBak, mainGo)
 			if err != nil {
 				return err
@@ -101,3 +108,227 @@ func restoreMain(project common.AppProject) error {
 
 	return nil
 }
+
+func readAppDescriptorMap(project common.AppProject) (map[string]interface{}, error) {
+
+	appDescriptorData, err := ioutil.ReadFile(filepath.Join(project.Dir(), fileAIflowJson))
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as written
+	decoder := json.NewDecoder(bytes.NewReader(appDescriptorData))
+	decoder.UseNumber()
+
+	var appObj map[string]interface{}
+	err = decoder.Decode(&appObj)
+	if err != nil {
+		return nil, err
+	}
+
+	return appObj, nil
+}
+
+// writeAppDescriptorMap writes the descriptor with the key order, indentation and final newline of the current
+// AIflow.json so that an edit only changes the edited values, the new keys follow the existing ones
+func writeAppDescriptorMap(project common.AppProject, appObj map[string]interface{}) error {
+	return writeJsonFileInPlace(filepath.Join(project.Dir(), fileAIflowJson), appObj)
+}
+
+// writeJsonFileInPlace writes the object with the key order, indentation and final newline of the current file
+func writeJsonFileInPlace(path string, obj map[string]interface{}) error {
+
+	var order *jsonKeyOrder
+	indent := "  "
+	newline := false
+	if current, err := ioutil.ReadFile(path); err == nil {
+		order, _ = readJsonKeyOrder(json.NewDecoder(bytes.NewReader(current)))
+		indent = jsonIndent(current)
+		newline = bytes.HasSuffix(current, []byte("\n"))
+	}
+
+	var buf bytes.Buffer
+	err := writeOrderedJson(&buf, obj, order, indent, "")
+	if err != nil {
+		return err
+	}
+	if newline {
+		buf.WriteString("\n")
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// jsonKeyOrder is the order of the keys of the objects of a JSON document
+type jsonKeyOrder struct {
+	keys     []string
+	children map[string]*jsonKeyOrder
+	elements []*jsonKeyOrder
+}
+
+// readJsonKeyOrder reads the key order of the next JSON value of the decoder, nil for a scalar
+func readJsonKeyOrder(decoder *json.Decoder) (*jsonKeyOrder, error) {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		order := &jsonKeyOrder{children: make(map[string]*jsonKeyOrder)}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string)
+			order.keys = append(order.keys, key)
+			if order.children[key], err = readJsonKeyOrder(decoder); err != nil {
+				return nil, err
+			}
+		}
+		_, err = decoder.Token()
+		return order, err
+	case json.Delim('['):
+		order := &jsonKeyOrder{}
+		for decoder.More() {
+			element, err := readJsonKeyOrder(decoder)
+			if err != nil {
+				return nil, err
+			}
+			order.elements = append(order.elements, element)
+		}
+		_, err = decoder.Token()
+		return order, err
+	}
+
+	return nil, nil
+}
+
+// jsonIndent returns the indentation of the first indented line of the document
+func jsonIndent(doc []byte) string {
+	for _, line := range strings.Split(string(doc), "\n")[1:] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// writeOrderedJson writes the value indented like json.MarshalIndent, with the keys of the objects in the order of
+// the original document, the keys it doesn't have sorted after them, and without escaping <, > and &
+func writeOrderedJson(w *bytes.Buffer, value interface{}, order *jsonKeyOrder, indent, prefix string) error {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+
+		var keys []string
+		found := make(map[string]bool)
+		if order != nil {
+			for _, key := range order.keys {
+				if _, ok := v[key]; ok && !found[key] {
+					found[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		var added []string
+		for key := range v {
+			if !found[key] {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		keys = append(keys, added...)
+
+		w.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + prefix + indent)
+			if err := writeJsonScalar(w, key); err != nil {
+				return err
+			}
+			w.WriteString(": ")
+
+			var child *jsonKeyOrder
+			if order != nil {
+				child = order.children[key]
+			}
+			if err := writeOrderedJson(w, v[key], child, indent, prefix+indent); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n" + prefix + "}")
+
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+
+		w.WriteString("[")
+		for i, element := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + prefix + indent)
+
+			var child *jsonKeyOrder
+			if order != nil && i < len(order.elements) {
+				child = order.elements[i]
+			}
+			if err := writeOrderedJson(w, element, child, indent, prefix+indent); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n" + prefix + "]")
+
+	case nil, string, bool, json.Number, float64, int:
+		return writeJsonScalar(w, v)
+
+	default:
+		// other types, such as structs or typed slices, are written as the generic value of their JSON
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var generic interface{}
+		if err := decoder.Decode(&generic); err != nil {
+			return err
+		}
+
+		if _, isObject := generic.(map[string]interface{}); isObject {
+			return writeOrderedJson(w, generic, order, indent, prefix)
+		}
+		if _, isArray := generic.([]interface{}); isArray {
+			return writeOrderedJson(w, generic, order, indent, prefix)
+		}
+		return writeJsonScalar(w, generic)
+	}
+
+	return nil
+}
+
+func writeJsonScalar(w io.Writer, value interface{}) error {
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
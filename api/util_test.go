@@ -0,0 +1,72 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderedAppJson = `{
+  "name": "app",
+  "type": "AIflow:app",
+  "version": "1.0.0",
+  "imports": [
+    "github.com/r2d2-ai/contrib/activity/log"
+  ],
+  "triggers": [
+    {
+      "id": "timer",
+      "ref": "#timer",
+      "settings": {
+        "port": 8080,
+        "rate": 0.5,
+        "empty": {}
+      },
+      "handlers": [
+        {
+          "action": {
+            "ref": "#flow",
+            "input": {
+              "big": "=$.a > 5 && $.b < 3"
+            }
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+
+func TestWriteAppDescriptorMap(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	project := NewAppProject(tempDir)
+	appJsonPath := filepath.Join(tempDir, fileAIflowJson)
+	assert.Nil(t, ioutil.WriteFile(appJsonPath, []byte(orderedAppJson), 0644))
+
+	appObj, err := readAppDescriptorMap(project)
+	assert.Nil(t, err)
+	assert.Nil(t, writeAppDescriptorMap(project, appObj))
+
+	buf, err := ioutil.ReadFile(appJsonPath)
+	assert.Nil(t, err)
+	assert.Equal(t, orderedAppJson, string(buf))
+
+	// an edit only changes the edited value, new keys follow the existing ones
+	appObj["version"] = "1.1.0"
+	appObj["description"] = "edited"
+	assert.Nil(t, writeAppDescriptorMap(project, appObj))
+
+	buf, err = ioutil.ReadFile(appJsonPath)
+	assert.Nil(t, err)
+	expected := strings.Replace(orderedAppJson, `"1.0.0"`, `"1.1.0"`, 1)
+	expected = strings.Replace(expected, "  ]\n}\n", "  ],\n  \"description\": \"edited\"\n}\n", 1)
+	assert.Equal(t, expected, string(buf))
+}
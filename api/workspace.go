@@ -0,0 +1,317 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+const (
+	FileWorkspace = "aiflow.work"
+)
+
+// Workspace is a set of apps listed by an aiflow.work file, the apps of a workspace are built and maintained together
+type Workspace struct {
+	Dir     string          // directory of the aiflow.work file
+	Apps    []string        // directories of the apps, in the order of the file
+	Session *common.Session // session the sessions of the apps are forked from, the default session if nil
+}
+
+// WorkspaceResult is the outcome of an operation on an app of the workspace
+type WorkspaceResult struct {
+	App      string // directory of the app relative to the workspace
+	Duration time.Duration
+	Err      error
+	Build    *common.BuildResult // result of the build for ws build
+	Outdated []*OutdatedModule   // outdated modules for ws outdated
+}
+
+// FindWorkspace returns the workspace of the aiflow.work file found in the directory or the closest of its parents
+func FindWorkspace(dir string) (*Workspace, error) {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		if util.FileExists(filepath.Join(current, FileWorkspace)) {
+			return ReadWorkspace(filepath.Join(current, FileWorkspace))
+		}
+		if filepath.Dir(current) == current {
+			return nil, fmt.Errorf("%s not found in '%s' or its parent directories", FileWorkspace, dir)
+		}
+	}
+}
+
+// ReadWorkspace reads an aiflow.work file, it lists a directory of an app per line relative to the file, the lines
+// starting with # are comments and the patterns of filepath.Match list the matching app directories
+func ReadWorkspace(workspaceFile string) (*Workspace, error) {
+
+	workspaceFile, err := filepath.Abs(workspaceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(workspaceFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ws := &Workspace{Dir: filepath.Dir(workspaceFile)}
+	found := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern := filepath.FromSlash(line)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(ws.Dir, pattern)
+		}
+
+		var dirs []string
+		if strings.ContainsAny(line, "*?[") {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid pattern '%s': %v", FileWorkspace, lineNum, line, err)
+			}
+			// a pattern only lists the directories that are apps
+			for _, match := range matches {
+				if util.FileExists(filepath.Join(match, fileAIflowJson)) {
+					dirs = append(dirs, match)
+				}
+			}
+		} else {
+			if !util.DirExists(pattern) {
+				return nil, fmt.Errorf("%s:%d: app directory '%s' not found", FileWorkspace, lineNum, line)
+			}
+			dirs = append(dirs, pattern)
+		}
+
+		for _, dir := range dirs {
+			if !found[dir] {
+				found[dir] = true
+				ws.Apps = append(ws.Apps, dir)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ws.Apps) == 0 {
+		return nil, fmt.Errorf("%s doesn't list any app", workspaceFile)
+	}
+
+	return ws, nil
+}
+
+// RunWorkspace runs the operation on the apps of the workspace, at most jobs at a time or one per CPU if jobs isn't
+// positive, and returns the results in the order of the apps
+func RunWorkspace(ws *Workspace, jobs int, operation func(project common.AppProject, result *WorkspaceResult) error) []*WorkspaceResult {
+
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]*WorkspaceResult, len(ws.Apps))
+	for i, dir := range ws.Apps {
+		results[i] = &WorkspaceResult{App: ws.relativeDir(dir)}
+	}
+
+	projects := ws.projects()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+
+	for i, dir := range ws.Apps {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(result *WorkspaceResult, dir string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			start := time.Now()
+			project := projects[dir]
+			if result.Err = project.Validate(); result.Err == nil {
+				result.Err = operation(project, result)
+			}
+			result.Duration = time.Since(start)
+		}(results[i], dir)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// BuildWorkspace builds the apps of the workspace concurrently, the modules of the apps are downloaded first, one
+// go.mod at a time, so that the builds don't download the same modules concurrently
+func BuildWorkspace(ws *Workspace, jobs int, options common.BuildOptions) []*WorkspaceResult {
+
+	downloads := &workspaceDownloads{ws: ws, errs: make(map[string]error)}
+
+	return RunWorkspace(ws, jobs, func(project common.AppProject, result *WorkspaceResult) error {
+		if err := downloads.download(project, options); err != nil {
+			return fmt.Errorf("unable to download the modules: %v", err)
+		}
+
+		var err error
+		result.Build, err = BuildProject(project, options)
+		return err
+	})
+}
+
+// SyncWorkspace synchronizes the Go imports of the apps of the workspace with their descriptors
+func SyncWorkspace(ws *Workspace, jobs int) []*WorkspaceResult {
+	return RunWorkspace(ws, jobs, func(project common.AppProject, result *WorkspaceResult) error {
+		return SyncProjectImports(project)
+	})
+}
+
+// ValidateWorkspace validates the projects of the workspace and their descriptors with the overlays of the
+// environment applied
+func ValidateWorkspace(ws *Workspace, jobs int, env string) []*WorkspaceResult {
+	return RunWorkspace(ws, jobs, func(project common.AppProject, result *WorkspaceResult) error {
+		return ValidateProject(project, env)
+	})
+}
+
+// OutdatedWorkspace lists the outdated modules of the apps of the workspace
+func OutdatedWorkspace(ws *Workspace, jobs int) []*WorkspaceResult {
+	return RunWorkspace(ws, jobs, func(project common.AppProject, result *WorkspaceResult) error {
+		var err error
+		result.Outdated, err = ListOutdatedModules(project)
+		return err
+	})
+}
+
+// PrintWorkspaceReport prints the results of the apps and a summary, it returns the number of apps that failed
+func PrintWorkspaceReport(w io.Writer, results []*WorkspaceResult) int {
+
+	width := 0
+	for _, result := range results {
+		if len(result.App) > width {
+			width = len(result.App)
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "FAIL"
+			failed++
+		}
+
+		detail := result.Duration.Round(time.Millisecond).String()
+		if result.Build != nil && result.Build.Cached {
+			detail += " (up to date)"
+		}
+		if result.Err == nil && result.Outdated != nil {
+			detail += fmt.Sprintf(" (%d outdated)", len(result.Outdated))
+		}
+
+		fmt.Fprintf(w, "%-4s  %-*s  %s\n", status, width, result.App, detail)
+
+		if result.Err != nil {
+			for _, line := range strings.Split(strings.TrimSpace(result.Err.Error()), "\n") {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+		for _, module := range result.Outdated {
+			fmt.Fprintf(w, "      %s %s => %s\n", module.Path, module.Version, module.Latest)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d apps, %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+
+	return failed
+}
+
+// workspaceDownloads runs 'go mod download' once for each distinct go.mod and go.sum of the apps being built, one
+// at a time, the apps requiring the same modules share the download
+type workspaceDownloads struct {
+	ws   *Workspace
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+// download downloads the modules of the app unless it is up to date, its build doesn't download them again
+func (d *workspaceDownloads) download(project common.AppProject, options common.BuildOptions) error {
+
+	if !options.Force && options.Shim == "" {
+		if key, err := buildCacheKey(project, options); err == nil && cachedBuild(project, key, options) != nil {
+			return nil
+		}
+	}
+
+	h := sha256.New()
+	for _, file := range []string{"go.mod", "go.sum"} {
+		if err := hashInputFile(h, project.SrcDir(), filepath.Join(project.SrcDir(), file)); err != nil {
+			return err
+		}
+	}
+	key := fmt.Sprintf("%x", h.Sum(nil))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err, ok := d.errs[key]
+	if !ok {
+		logf(project, "Downloading the modules of %s...\n", d.ws.relativeDir(project.Dir()))
+		err = execCmd(project, exec.Command("go", "mod", "download"), project.SrcDir())
+		d.errs[key] = err
+	}
+
+	if dm, ok := project.DepManager().(*util.ModDepManager); ok && err == nil {
+		dm.SetModulesDownloaded()
+	}
+
+	return err
+}
+
+// projects returns the projects of the apps by directory, each bound to its own session forked from the session of
+// the workspace so that the apps don't share their aliases
+func (ws *Workspace) projects() map[string]common.AppProject {
+
+	session := ws.Session
+	if session == nil {
+		session = common.DefaultSession()
+	}
+
+	projects := make(map[string]common.AppProject)
+	for _, dir := range ws.Apps {
+		projects[dir] = NewAppProjectInSession(session.Fork(), dir)
+	}
+
+	return projects
+}
+
+// relativeDir returns the directory relative to the workspace if it is inside it
+func (ws *Workspace) relativeDir(dir string) string {
+	if rel, err := filepath.Rel(ws.Dir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return dir
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWorkspaceTestDir(t *testing.T, apps ...string) string {
+
+	tempDir, err := ioutil.TempDir("", "AIflow")
+	assert.Nil(t, err)
+
+	for _, app := range apps {
+		assert.Nil(t, os.MkdirAll(filepath.Join(tempDir, app, dirSrc), os.ModePerm))
+		for _, file := range []string{fileAIflowJson, filepath.Join(dirSrc, fileImportsGo), filepath.Join(dirSrc, "go.mod")} {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, app, file), []byte("{}"), 0644))
+		}
+	}
+
+	return tempDir
+}
+
+func TestReadWorkspace(t *testing.T) {
+
+	tempDir := newWorkspaceTestDir(t, "orders", "apps/payments", "apps/shipping")
+	defer os.RemoveAll(tempDir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(tempDir, "apps", "docs"), os.ModePerm))
+
+	content := "# apps of the workspace\norders\n\napps/*\napps/payments\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, FileWorkspace), []byte(content), 0644))
+
+	ws, err := FindWorkspace(filepath.Join(tempDir, "apps", "docs"))
+	assert.Nil(t, err)
+	assert.Equal(t, tempDir, ws.Dir)
+	assert.Equal(t, []string{"orders", "apps/payments", "apps/shipping"}, []string{ws.relativeDir(ws.Apps[0]), ws.relativeDir(ws.Apps[1]), ws.relativeDir(ws.Apps[2])})
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tempDir, FileWorkspace), []byte("orders\nmissing\n"), 0644))
+	_, err = ReadWorkspace(filepath.Join(tempDir, FileWorkspace))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), ":2:")
+}
+
+func TestRunWorkspace(t *testing.T) {
+
+	tempDir := newWorkspaceTestDir(t, "a", "b", "c", "d")
+	defer os.RemoveAll(tempDir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(tempDir, "invalid"), os.ModePerm))
+
+	ws := &Workspace{Dir: tempDir}
+	for _, app := range []string{"a", "b", "invalid", "c", "d"} {
+		ws.Apps = append(ws.Apps, filepath.Join(tempDir, app))
+	}
+
+	var running, maxRunning int32
+	var sessions sync.Map
+	results := RunWorkspace(ws, 2, func(project common.AppProject, result *WorkspaceResult) error {
+		// each app has its own session
+		session := common.ProjectSession(project)
+		assert.True(t, session != common.DefaultSession())
+		_, shared := sessions.LoadOrStore(session, true)
+		assert.False(t, shared)
+
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	assert.True(t, maxRunning <= 2)
+	assert.Len(t, results, 5)
+	for i, app := range []string{"a", "b", "invalid", "c", "d"} {
+		assert.Equal(t, app, results[i].App)
+		assert.Equal(t, app == "invalid", results[i].Err != nil)
+	}
+}
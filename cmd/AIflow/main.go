@@ -15,13 +15,15 @@ var Version string = ""
 //go:generate go run gen/version.go
 func main() {
 
-	if util.GetGoPath() == "" {
+	//Initialize the commands
+	_ = os.Setenv("GO111MODULE", "auto")
+	commands.Initialize(Version)
+
+	// doctor diagnoses a missing or misconfigured toolchain
+	if util.GetGoPath() == "" && !commands.IsDoctorCmd(os.Args[1:]) {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: GOPATH must be set before running AIflow cli\n")
 		os.Exit(1)
 	}
 
-	//Initialize the commands
-	_ = os.Setenv("GO111MODULE", "auto")
-	commands.Initialize(Version)
 	commands.Execute()
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 
 	"github.com/r2d2-ai/aiflow-cli/api"
 	"github.com/r2d2-ai/aiflow-cli/common"
@@ -15,14 +14,29 @@ var buildShim string
 var buildOptimize bool
 var buildEmbed bool
 var syncImport bool
+var buildTargets []string
+var buildEnv string
+var buildCompress bool
+var buildEncrypt bool
+var buildReproducible bool
+var buildForce bool
+var buildStamp bool
 var AIflowJsonFile string
 
 func init() {
 	buildCmd.Flags().StringVarP(&buildShim, "shim", "", "", "use shim trigger")
-	buildCmd.Flags().BoolVarP(&buildOptimize, "optimize", "o", false, "optimize build")
-	buildCmd.Flags().BoolVarP(&buildEmbed, "embed", "e", false, "embed configuration in binary")
+	buildCmd.PersistentFlags().BoolVarP(&buildOptimize, "optimize", "o", false, "optimize build")
+	buildCmd.PersistentFlags().BoolVarP(&buildEmbed, "embed", "e", false, "embed configuration in binary")
 	buildCmd.Flags().StringVarP(&AIflowJsonFile, "file", "f", "", "specify a AIflow.json to build")
 	buildCmd.Flags().BoolVarP(&syncImport, "sync", "s", false, "sync imports during build")
+	buildCmd.PersistentFlags().StringSliceVarP(&buildTargets, "target", "t", nil, "build for the GOOS/GOARCH targets (ex. linux/amd64)")
+	buildCmd.PersistentFlags().StringVarP(&buildEnv, "env", "", "", "embed the configuration with the overlays of the environment")
+	buildCmd.PersistentFlags().BoolVarP(&buildCompress, "compress", "", false, "embed the configuration gzipped")
+	buildCmd.PersistentFlags().BoolVarP(&buildEncrypt, "encrypt", "", false, "embed the configuration encrypted with the key of "+api.EnvConfigKey)
+	buildCmd.Flags().BoolVarP(&buildReproducible, "reproducible", "", false, "build without the paths, build ids and time of the build machine")
+	buildCmd.Flags().BoolVarP(&buildForce, "force", "", false, "build even if the executables are up to date")
+	buildCmd.PersistentFlags().BoolVarP(&buildStamp, "stamp", "", false, "stamp the build information into the app, printed by the app with --version")
+	buildCmd.AddCommand(buildVerifyCmd)
 	rootCmd.AddCommand(buildCmd)
 }
 
@@ -34,9 +48,15 @@ var buildCmd = &cobra.Command{
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
+
+		configString(cmd, "shim", common.ConfigBuildShim, &buildShim)
+		options := buildOptions(cmd)
+		options.Shim = buildShim
+		options.Reproducible = buildReproducible
+		options.Force = buildForce
+
 		if AIflowJsonFile == "" {
 			preRun(cmd, args, verbose)
-			options := common.BuildOptions{Shim: buildShim, OptimizeImports: buildOptimize, EmbedConfig: buildEmbed}
 
 			if syncImport {
 				err = api.SyncProjectImports(common.CurrentProject())
@@ -46,7 +66,7 @@ var buildCmd = &cobra.Command{
 				}
 			}
 
-			err = api.BuildProject(common.CurrentProject(), options)
+			_, err = api.BuildProject(common.CurrentProject(), options)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error building project: %v\n", err)
 				os.Exit(1)
@@ -55,6 +75,11 @@ var buildCmd = &cobra.Command{
 			//If a jsonFile is specified in the build.
 			//Create a new project in the temp folder and copy the bin.
 
+			if buildEnv != "" {
+				fmt.Fprintf(os.Stderr, "Error building project: --env requires a project, the overlays aren't available with --file\n")
+				os.Exit(1)
+			}
+
 			tempDir, err := api.GetTempDir()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error getting temp dir: %v\n", err)
@@ -70,20 +95,62 @@ var buildCmd = &cobra.Command{
 
 			common.SetCurrentProject(tempProject)
 
-			options := common.BuildOptions{Shim: buildShim, OptimizeImports: buildOptimize, EmbedConfig: buildEmbed}
-
-			err = api.BuildProject(common.CurrentProject(), options)
+			result, err := api.BuildProject(common.CurrentProject(), options)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error building temp project: %v\n", err)
 				os.Exit(1)
 			}
 
-			copyBin(verbose, tempProject)
+			copyBin(verbose, tempProject, result)
 		}
 	},
 }
 
-func copyBin(verbose bool, tempProject common.AppProject) {
+var buildVerifyCmd = &cobra.Command{
+	Use:   "verify [flags]",
+	Short: "verify the build is reproducible",
+	Long:  "Rebuilds the application with --reproducible and compares the executables with those of the bin directory",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		preRun(cmd, args, verbose)
+
+		verification, err := api.VerifyBuild(common.CurrentProject(), buildOptions(cmd))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error verifying build: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, exe := range verification.Executables {
+			if exe.Matches() {
+				fmt.Printf("%s: reproduced (sha256 %s)\n", exe.Path, exe.Actual)
+			} else {
+				fmt.Printf("%s: differs (sha256 %s, rebuilt %s)\n", exe.Path, exe.Expected, exe.Actual)
+			}
+		}
+
+		if !verification.Reproducible() {
+			fmt.Fprintf(os.Stderr, "Error verifying build: the build isn't reproducible\n")
+			os.Exit(1)
+		}
+	},
+}
+
+// buildOptions returns the options of the build flags shared by build and build verify, the defaults are those of
+// the configuration
+func buildOptions(cmd *cobra.Command) common.BuildOptions {
+
+	configBool(cmd, "optimize", common.ConfigBuildOptimize, &buildOptimize)
+	configBool(cmd, "embed", common.ConfigBuildEmbed, &buildEmbed)
+	configList(cmd, "target", common.ConfigBuildTargets, &buildTargets)
+	configString(cmd, "env", common.ConfigBuildEnv, &buildEnv)
+	configBool(cmd, "compress", common.ConfigBuildCompress, &buildCompress)
+
+	return common.BuildOptions{OptimizeImports: buildOptimize, EmbedConfig: buildEmbed, Targets: buildTargets, Env: buildEnv,
+		CompressConfig: buildCompress, EncryptConfig: buildEncrypt, StampInfo: buildStamp}
+}
+
+func copyBin(verbose bool, tempProject common.AppProject, result *common.BuildResult) {
 
 	currDir, err := os.Getwd()
 	if err != nil {
@@ -95,7 +162,15 @@ func copyBin(verbose bool, tempProject common.AppProject) {
 		fmt.Printf("Copying the binary from  %s to %s \n", tempProject.BinDir(), currDir)
 	}
 
-	if runtime.GOOS == "windows" || api.GOOSENV == "windows" {
+	if len(result.Options.Targets) > 0 {
+		for _, exe := range result.Executables {
+			err = os.Rename(exe, filepath.Join(currDir, filepath.Base(exe)))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error renaming executable: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	} else if common.ProjectSession(tempProject).GOOS() == "windows" {
 		err = os.Rename(tempProject.Executable(), filepath.Join(currDir, "main.exe"))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error renaming executable: %v\n", err)
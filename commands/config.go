@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var profile string
+var configProject bool
+var configRenderEnv string
+
+var cliConfig *common.Config
+
+func init() {
+	configSetCmd.Flags().BoolVarP(&configProject, "project", "", false, "set in the project's "+common.FileProjectConfig+" instead of the user config")
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configRenderCmd.Flags().StringVarP(&configRenderEnv, "env", "", "", "apply the overlays of the environment")
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configRenderCmd)
+	rootCmd.AddCommand(configCmd)
+
+	cobra.OnInitialize(applyConfigEnv)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage CLI configuration",
+	Long: `Manages the CLI configuration, settings are read from the user config file and the project's ` + common.FileProjectConfig + `.
+Flags take precedence over environment variables, which take precedence over the project and user config files.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		common.SetVerbose(verbose)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "get a setting",
+	Long:  "Prints the resolved value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if common.ConfigEnvVar(args[0]) == "" {
+			fmt.Fprintf(os.Stderr, "Error getting setting: unknown setting '%s'\n", args[0])
+			os.Exit(1)
+		}
+
+		if val, ok := currentConfig().Get(args[0]); ok {
+			fmt.Println(val)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [flags] <key> <value>",
+	Short: "set a setting",
+	Long:  "Sets a setting in the user config file, or in the profile selected with --profile",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := common.GetUserConfigFile()
+		if configProject {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error determining working directory: %v\n", err)
+				os.Exit(1)
+			}
+			configFile = filepath.Join(currentDir, common.FileProjectConfig)
+		}
+
+		cf, err := common.LoadConfigFile(configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+
+		err = cf.Set(profile, args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting '%s': %v\n", args[0], err)
+			os.Exit(1)
+		}
+
+		err = cf.Save()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list settings",
+	Long:  "Lists the resolved settings and where they are set",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		for _, val := range currentConfig().Values() {
+			fmt.Printf("%s=%s (%s)\n", val.Key, val.String(), val.Source)
+		}
+	},
+}
+
+var configRenderCmd = &cobra.Command{
+	Use:   "render [flags]",
+	Short: "render the app descriptor",
+	Long:  "Prints the effective " + fJsonFile + " of the project, with the overlays of the environment selected with --env applied",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configString(cmd, "env", common.ConfigBuildEnv, &configRenderEnv)
+
+		currentDir, err := os.Getwd()
+		if err != nil || !isProjectDir(currentDir) {
+			fmt.Fprintf(os.Stderr, "Error rendering app descriptor: not in a project directory\n")
+			os.Exit(1)
+		}
+
+		project := api.NewAppProject(currentDir)
+		appJson, err := api.RenderAppDescriptor(project, configRenderEnv)
+		if err != nil {
+			if envs := api.ListEnvs(project); len(envs) > 0 {
+				fmt.Fprintf(os.Stderr, "Error rendering app descriptor: %v (environments: %s)\n", err, strings.Join(envs, ", "))
+			} else {
+				fmt.Fprintf(os.Stderr, "Error rendering app descriptor: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Println(appJson)
+	},
+}
+
+// currentConfig loads the configuration for the current directory and selected profile
+func currentConfig() *common.Config {
+
+	if cliConfig != nil {
+		return cliConfig
+	}
+
+	config, err := loadConfig(profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	cliConfig = config
+
+	return cliConfig
+}
+
+func loadConfig(profile string) (*common.Config, error) {
+
+	projectDir := ""
+	if currentDir, err := os.Getwd(); err == nil && isProjectDir(currentDir) {
+		projectDir = currentDir
+	}
+
+	return common.LoadConfig(projectDir, profile)
+}
+
+// isProjectDir checks if the directory contains an app descriptor
+func isProjectDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, fJsonFile))
+	return err == nil
+}
+
+// applyConfigEnv sets the Go environment overrides of the configuration for the go commands run by the CLI
+func applyConfigEnv() {
+
+	config, err := loadConfig(profile)
+	if err != nil {
+		// reported by the commands using the configuration
+		return
+	}
+
+	for _, key := range []string{common.ConfigGoProxy, common.ConfigGoPrivate} {
+		if val, ok := config.Get(key); ok {
+			_ = os.Setenv(common.ConfigEnvVar(key), val)
+		}
+	}
+}
+
+// configString sets the value from the configuration when the flag wasn't specified
+func configString(cmd *cobra.Command, flag, key string, value *string) {
+	if cmd.Flags().Changed(flag) {
+		return
+	}
+	if val, ok := currentConfig().Get(key); ok {
+		*value = val
+	}
+}
+
+// configBool sets the value from the configuration when the flag wasn't specified
+func configBool(cmd *cobra.Command, flag, key string, value *bool) {
+	if cmd.Flags().Changed(flag) {
+		return
+	}
+	if val, ok := currentConfig().GetBool(key); ok {
+		*value = val
+	}
+}
+
+// configList sets the value from the configuration when the flag wasn't specified
+func configList(cmd *cobra.Command, flag, key string, value *[]string) {
+	if cmd.Flags().Changed(flag) {
+		return
+	}
+	if val, ok := currentConfig().GetList(key); ok {
+		*value = val
+	}
+}
+
+// profileFromArgs returns the profile specified on the command line, it is used before the flags are parsed
+func profileFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--profile" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--profile=") {
+			return strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+	return ""
+}
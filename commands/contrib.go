@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/spf13/cobra"
+)
+
+var contribDocsOutput string
+
+func init() {
+	contribDocsCmd.Flags().StringVarP(&contribDocsOutput, "output", "o", "", "write the README to the specified file")
+	contribCmd.AddCommand(contribDocsCmd)
+	rootCmd.AddCommand(contribCmd)
+}
+
+var contribCmd = &cobra.Command{
+	Use:   "contrib",
+	Short: "manage contributions",
+	Long:  "Manage AIflow contribution projects",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		api.SetVerbose(verbose)
+	},
+}
+
+var contribDocsCmd = &cobra.Command{
+	Use:   "docs <path>",
+	Short: "generate contribution README",
+	Long:  "Generates a Markdown README from the contribution's descriptor",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		out, closeOut := docsWriter(contribDocsOutput)
+		defer closeOut()
+
+		err := api.WriteContribDocs(out, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating contribution documentation: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -5,15 +5,18 @@ import (
 	"os"
 
 	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
 	"github.com/spf13/cobra"
 )
 
 var AIflowJsonPath string
 var coreVersion string
+var createMigrateAppModel bool
 
 func init() {
 	CreateCmd.Flags().StringVarP(&AIflowJsonPath, "file", "f", "", "specify a AIflow.json to create project from")
 	CreateCmd.Flags().StringVarP(&coreVersion, "cv", "", "", "specify core library version (ex. master)")
+	CreateCmd.Flags().BoolVarP(&createMigrateAppModel, "migrate", "", false, "migrate the AIflow.json of an older app model")
 	rootCmd.AddCommand(CreateCmd)
 }
 
@@ -26,6 +29,8 @@ var CreateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 
 		api.SetVerbose(verbose)
+		configString(cmd, "cv", common.ConfigCoreVersion, &coreVersion)
+
 		appName := ""
 		if len(args) > 0 {
 			appName = args[0]
@@ -36,7 +41,7 @@ var CreateCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "Error determining working directory: %v\n", err)
 			os.Exit(1)
 		}
-		_, err = api.CreateProject(currentDir, appName, AIflowJsonPath, coreVersion)
+		_, err = api.CreateProjectWithOptions(currentDir, appName, AIflowJsonPath, coreVersion, api.CreateOptions{MigrateAppModel: createMigrateAppModel})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating project: %v\n", err)
 			os.Exit(1)
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var docsOutput string
+
+func init() {
+	docsCmd.Flags().StringVarP(&docsOutput, "output", "o", "", "write the documentation to the specified file")
+	rootCmd.AddCommand(docsCmd)
+}
+
+var docsCmd = &cobra.Command{
+	Use:   "docs [flags]",
+	Short: "generate app documentation",
+	Long:  `Generates Markdown documentation for the app's triggers, flows and contributions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		out, closeOut := docsWriter(docsOutput)
+		defer closeOut()
+
+		err := api.WriteAppDocs(out, common.CurrentProject())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating app documentation: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func docsWriter(output string) (*os.File, func()) {
+	if output == "" {
+		return os.Stdout, func() {}
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+		os.Exit(1)
+	}
+
+	return f, func() { _ = f.Close() }
+}
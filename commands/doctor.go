@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "diagnose the CLI environment",
+	Long:  "Checks the Go toolchain, module cache, proxy settings, required tools and CLI source, and the integrity of the project when run in a project directory",
+	Args:  cobra.NoArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		api.SetVerbose(verbose)
+		common.SetVerbose(verbose)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var project common.AppProject
+		if currentDir, err := os.Getwd(); err == nil && isProjectDir(currentDir) {
+			project = api.NewAppProject(currentDir)
+		}
+
+		checks := api.RunDoctor(project)
+		if api.PrintDoctorReport(os.Stdout, checks) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// IsDoctorCmd returns whether the arguments run the doctor command, whatever the flags before it
+func IsDoctorCmd(args []string) bool {
+	cmd, _, err := rootCmd.Find(args)
+	return err == nil && cmd == doctorCmd
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var engineInitForce bool
+var engineSetAction string
+var engineServiceSettings []string
+
+func init() {
+	engineInitCmd.Flags().BoolVarP(&engineInitForce, "force", "", false, "replace an existing engine.json")
+	engineSetCmd.Flags().StringVarP(&engineSetAction, "action", "a", "", "set an action setting of the action ref")
+	engineServiceAddCmd.Flags().StringArrayVarP(&engineServiceSettings, "setting", "s", nil, "service setting (ex. port=9999)")
+
+	engineCmd.AddCommand(engineInitCmd)
+	engineCmd.AddCommand(engineShowCmd)
+	engineCmd.AddCommand(engineSetCmd)
+	engineCmd.AddCommand(engineServiceCmd)
+	engineServiceCmd.AddCommand(engineServiceAddCmd)
+	engineServiceCmd.AddCommand(engineServiceRemoveCmd)
+	rootCmd.AddCommand(engineCmd)
+}
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "manage the engine configuration",
+	Long:  "Manage the engine configuration of the project, engine.json",
+}
+
+var engineInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "create engine.json",
+	Long:  "Creates the engine.json of the project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		err := api.InitEngineConfig(common.CurrentProject(), engineInitForce)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating engine.json: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var engineShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show engine.json",
+	Long:  "Shows the engine.json of the project and the problems of its services",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		err := api.ShowEngineConfig(common.CurrentProject())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error showing engine.json: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var engineSetCmd = &cobra.Command{
+	Use:   "set [flags] <key> <value>",
+	Short: "set an engine setting",
+	Long:  "Sets an engine setting, the settings are: " + strings.Join(api.EngineSettingKeys(), ", "),
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		err := api.SetEngineSetting(common.CurrentProject(), engineSetAction, args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting engine setting: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var engineServiceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "manage the engine services",
+	Long:  "Manage the services of engine.json",
+}
+
+var engineServiceAddCmd = &cobra.Command{
+	Use:   "add [flags] <service>",
+	Short: "add a service",
+	Long:  "Installs a service contribution and adds it to the services of engine.json",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		settings := make(map[string]string)
+		for _, setting := range engineServiceSettings {
+			parts := strings.SplitN(setting, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				fmt.Fprintf(os.Stderr, "Error adding service: invalid setting '%s', expected name=value\n", setting)
+				os.Exit(1)
+			}
+			settings[parts[0]] = parts[1]
+		}
+
+		err := api.AddEngineService(common.CurrentProject(), args[0], settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding service: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var engineServiceRemoveCmd = &cobra.Command{
+	Use:   "remove <service>",
+	Short: "remove a service",
+	Long:  "Removes a service from engine.json, by import path or #alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		err := api.RemoveEngineService(common.CurrentProject(), args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing service: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
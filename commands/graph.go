@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var graphFormat string
+var graphOutput string
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "", "dot", "output format [dot, mermaid, html]")
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "", "write the graph to the specified file")
+	rootCmd.AddCommand(graphCmd)
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [flags]",
+	Short: "export the app topology",
+	Long:  `Exports the app's triggers, handlers, actions, flows and activities as a graph.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		graph, err := api.GetAppGraph(common.CurrentProject())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building app graph: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := os.Stdout
+		if graphOutput != "" {
+			out, err = os.Create(graphOutput)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+				os.Exit(1)
+			}
+			defer out.Close()
+		}
+
+		err = api.WriteAppGraph(out, graph, graphFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing app graph: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -14,8 +14,17 @@ func init() {
 	importsCmd.AddCommand(importsSyncCmd)
 	importsCmd.AddCommand(importsResolveCmd)
 	importsCmd.AddCommand(importsListCmd)
+	importsCmd.AddCommand(importsAliasCmd)
+
+	importsAliasCmd.Flags().StringVarP(&aliasContribType, "type", "t", "", "contribution type of the aliased import [trigger, action, activity]")
+	importsAliasCmd.Flags().BoolVarP(&aliasQualify, "qualify", "", false, "replace alias refs with fully qualified refs")
+	importsAliasCmd.Flags().BoolVarP(&aliasUnqualify, "unqualify", "", false, "replace fully qualified refs with alias refs")
 }
 
+var aliasContribType string
+var aliasQualify bool
+var aliasUnqualify bool
+
 var importsCmd = &cobra.Command{
 	Use:   "imports",
 	Short: "manage project imports",
@@ -69,3 +78,35 @@ var importsListCmd = &cobra.Command{
 		}
 	},
 }
+
+var importsAliasCmd = &cobra.Command{
+	Use:   "alias [flags] <alias> [newAlias]",
+	Short: "rename an import alias",
+	Long:  `Renames an import alias and updates all the refs that use it.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var err error
+
+		switch {
+		case aliasQualify && aliasUnqualify:
+			fmt.Fprintf(os.Stderr, "Error: --qualify and --unqualify cannot be used together\n")
+			os.Exit(1)
+		case aliasQualify:
+			err = api.QualifyImportRefs(common.CurrentProject(), args[0], aliasContribType)
+		case aliasUnqualify:
+			err = api.UnqualifyImportRefs(common.CurrentProject(), args[0], aliasContribType)
+		default:
+			if len(args) != 2 {
+				fmt.Fprintf(os.Stderr, "Error: new alias not specified\n")
+				os.Exit(1)
+			}
+			err = api.RenameImportAlias(common.CurrentProject(), args[0], args[1], aliasContribType)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating import alias: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
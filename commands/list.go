@@ -26,6 +26,10 @@ var listCmd = &cobra.Command{
 	Long:  "List installed AIflow contributions",
 	Run: func(cmd *cobra.Command, args []string) {
 
+		if output, ok := currentConfig().Get(common.ConfigOutput); ok && !cmd.Flags().Changed("json") {
+			json = output == "json"
+		}
+
 		if orphaned {
 			err := api.ListOrphanedRefs(common.CurrentProject(), json)
 			if err != nil {
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var migrateMappingFile string
+var migrateDryRun bool
+var migrateAppModel bool
+
+func init() {
+	migrateCmd.Flags().StringVarP(&migrateMappingFile, "mapping", "m", "", "mapping file of the legacy contributions to their replacements")
+	migrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "", false, "only report the migration")
+	migrateCmd.Flags().BoolVarP(&migrateAppModel, "app-model", "", false, "migrate AIflow.json to the latest app model supported by the core library")
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [flags]",
+	Short: "migrate legacy contributions or app model",
+	Long:  "Replaces the legacy contributions of the app with the equivalent modern contributions of a mapping file, or migrates AIflow.json to the latest app model",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if migrateAppModel {
+			err := api.MigrateProjectAppModel(common.CurrentProject(), migrateDryRun)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error migrating app model: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		_, err := api.MigrateLegacyApp(common.CurrentProject(), migrateMappingFile, migrateDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating app: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -3,16 +3,21 @@ package commands
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/r2d2-ai/aiflow-cli/common"
 	"github.com/spf13/cobra"
 )
 
+var pluginListVersions bool
+
 func init() {
+	pluginListCmd.Flags().BoolVarP(&pluginListVersions, "versions", "", false, "show the installed versions of the plugins")
 	pluginCmd.AddCommand(pluginInstallCmd)
 	pluginCmd.AddCommand(pluginListCmd)
 	pluginCmd.AddCommand(pluginUpdateCmd)
 	pluginCmd.AddCommand(pluginRemoveCmd)
+	pluginCmd.AddCommand(pluginRollbackCmd)
 	rootCmd.AddCommand(pluginCmd)
 }
 
@@ -52,8 +57,33 @@ var pluginListCmd = &cobra.Command{
 	Long:  "Lists installed CLI plugins",
 	Run: func(cmd *cobra.Command, args []string) {
 
+		var manifest *common.PluginManifest
+		if pluginListVersions {
+			var err error
+			manifest, err = common.LoadPluginManifest()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading plugin manifest: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
 		for _, pluginPkg := range common.GetPluginPkgs() {
-			fmt.Println(pluginPkg)
+			if manifest == nil {
+				fmt.Println(pluginPkg)
+				continue
+			}
+
+			entry := manifest.Get(pluginPkg)
+			if entry == nil || entry.Version == "" {
+				fmt.Printf("%s (unknown version)\n", pluginPkg)
+			} else {
+				fmt.Printf("%s %s (installed %s)\n", pluginPkg, entry.Version, entry.Installed.Format(time.RFC3339))
+			}
+		}
+
+		pluginDirs, _ := currentConfig().GetList(common.ConfigPluginDirs)
+		for _, plugin := range common.GetExternalPlugins(pluginDirs...) {
+			fmt.Printf("%s (external: %s)\n", plugin.Name, plugin.Path)
 		}
 	},
 }
@@ -99,3 +129,20 @@ var pluginUpdateCmd = &cobra.Command{
 		fmt.Printf("Updated plugin: %s\n", pluginPkg)
 	},
 }
+
+var pluginRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "rollback the last plugin change",
+	Long:  "Restores the CLI executable and plugins installed before the last plugin install, update or remove",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		err := RollbackCLI()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rolling back plugins: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Restored the previous CLI")
+	},
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+// addExternalPlugins adds a command for each external plugin that doesn't clash with an existing command
+func addExternalPlugins() {
+
+	// the flags aren't parsed yet, so the profile is taken from the arguments
+	var pluginDirs []string
+	if config, err := loadConfig(profileFromArgs(os.Args[1:])); err == nil {
+		pluginDirs, _ = config.GetList(common.ConfigPluginDirs)
+	}
+
+	addExternalPluginCmds(rootCmd, common.GetExternalPlugins(pluginDirs...))
+}
+
+// addExternalPluginCmds adds the commands of the plugins to the root command, the plugins named after an existing
+// command or alias are ignored
+func addExternalPluginCmds(root *cobra.Command, plugins []*common.ExternalPlugin) {
+	for _, plugin := range plugins {
+		if cmd, _, err := root.Find([]string{plugin.Name}); err == nil && cmd != root {
+			continue
+		}
+
+		root.AddCommand(newExternalPluginCmd(plugin))
+	}
+}
+
+func newExternalPluginCmd(plugin *common.ExternalPlugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                plugin.Name,
+		Short:              "external plugin " + plugin.Path,
+		Long:               "Runs the external plugin " + plugin.Path,
+		DisableFlagParsing: true,
+		PersistentPreRun:   func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			err := runExternalPlugin(plugin, args)
+			if err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+				fmt.Fprintf(os.Stderr, "Error running plugin '%s': %v\n", plugin.Name, err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func runExternalPlugin(plugin *common.ExternalPlugin, args []string) error {
+
+	pluginVerbose := verbose
+	for _, arg := range args {
+		if arg == "--verbose" {
+			pluginVerbose = true
+		}
+	}
+
+	env := os.Environ()
+	env = append(env, common.EnvPluginCLIVersion+"="+rootCmd.Version)
+	env = append(env, common.EnvPluginVerbose+"="+strconv.FormatBool(pluginVerbose))
+
+	// the project directory is only set when the plugin is run in a valid project
+	if currentDir, err := os.Getwd(); err == nil {
+		if api.NewAppProject(currentDir).Validate() == nil {
+			env = append(env, common.EnvPluginProjectDir+"="+currentDir)
+		}
+	}
+
+	cmd := exec.Command(plugin.Path, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
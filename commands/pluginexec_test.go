@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddExternalPluginCmds(t *testing.T) {
+
+	root := &cobra.Command{Use: "AIflow"}
+	root.AddCommand(&cobra.Command{Use: "build", Aliases: []string{"b"}, Run: func(cmd *cobra.Command, args []string) {}})
+
+	addExternalPluginCmds(root, []*common.ExternalPlugin{
+		{Name: "build", Path: "/plugins/AIflow-build"},
+		{Name: "b", Path: "/plugins/AIflow-b"},
+		{Name: "hello", Path: "/plugins/AIflow-hello"},
+	})
+
+	// the built-in commands and their aliases win over the plugins
+	cmd, _, err := root.Find([]string{"build"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", cmd.Short)
+	assert.Len(t, root.Commands(), 2)
+
+	cmd, _, err = root.Find([]string{"hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "external plugin /plugins/AIflow-hello", cmd.Short)
+}
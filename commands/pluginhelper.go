@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"text/template"
 	"time"
 
@@ -33,6 +34,18 @@ func UpdateCLI(pluginPkg string, updateOption int) error {
 		return err
 	}
 
+	pluginPkg, pluginVersion := splitPluginVersion(pluginPkg)
+
+	manifest, err := common.LoadPluginManifest()
+	if err != nil {
+		return err
+	}
+	previousManifest := &common.PluginManifest{}
+	for _, entry := range manifest.Plugins {
+		previous := *entry
+		previousManifest.Plugins = append(previousManifest.Plugins, &previous)
+	}
+
 	pluginSet := make(map[string]struct{})
 
 	//add installed plugins
@@ -76,13 +89,21 @@ func UpdateCLI(pluginPkg string, updateOption int) error {
 	}
 
 	for plugin := range pluginSet {
-		_, err := addPlugin(cliCmdPath, plugin)
+		// pin the version recorded in the manifest, unless a version is requested
+		version := ""
+		if plugin == pluginPkg && pluginVersion != "" {
+			version = pluginVersion
+		} else if entry := manifest.Get(plugin); entry != nil {
+			version = entry.Version
+		}
+
+		_, err := addPlugin(cliCmdPath, plugin, version)
 		if err != nil {
 			fmt.Println("error:", err)
 		}
 	}
 
-	if updateOption == UpdateOptUpdate {
+	if updateOption == UpdateOptUpdate && pluginVersion == "" {
 		err = util.ExecCmd(exec.Command("go", "get", "-u", pluginPkg), cliCmdPath)
 		if err != nil {
 			return err
@@ -100,23 +121,113 @@ func UpdateCLI(pluginPkg string, updateOption int) error {
 		return err
 	}
 
+	for _, entry := range previousManifest.Plugins {
+		if _, ok := pluginSet[entry.Package]; !ok {
+			manifest.Remove(entry.Package)
+		}
+	}
+	for plugin := range pluginSet {
+		manifest.Set(plugin, getPluginVersion(cliCmdPath, plugin))
+	}
+
 	cliExe := "AIflow"
 	if runtime.GOOS == "windows" || os.Getenv("GOOS") == "windows" {
 		cliExe = cliExe + ".exe"
 	}
 
+	err = backupCLI(exPath, previousManifest)
+	if err != nil {
+		return err
+	}
+
 	err = util.Copy(filepath.Join(cliCmdPath, cliExe), exPath, false)
 	if err != nil {
 		//fmt.Fprintf(os.Stderr, "Error: %v\n", osErr)
 		return err
 	}
 
-	return nil
+	return manifest.Save()
 }
 
-func addPlugin(cliCmdPath, pluginPkg string) (bool, error) {
+// RollbackCLI restores the CLI executable and plugin manifest saved before the last plugin update
+func RollbackCLI() error {
+
+	exPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	backupExe := filepath.Join(common.GetPluginBackupDir(), filepath.Base(exPath))
+	if _, err := os.Stat(backupExe); err != nil {
+		return fmt.Errorf("no CLI backup found in '%s'", common.GetPluginBackupDir())
+	}
+
+	manifest, err := common.LoadPluginManifestBackup()
+	if err != nil {
+		return err
+	}
+
+	err = util.Copy(backupExe, exPath, false)
+	if err != nil {
+		return err
+	}
+
+	err = manifest.Save()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(common.GetPluginBackupDir())
+}
+
+// backupCLI keeps a copy of the current CLI executable and its plugin manifest
+func backupCLI(exPath string, manifest *common.PluginManifest) error {
+
+	backupDir := common.GetPluginBackupDir()
+
+	err := os.MkdirAll(backupDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = util.Copy(exPath, filepath.Join(backupDir, filepath.Base(exPath)), true)
+	if err != nil {
+		return err
+	}
+
+	return manifest.SaveBackup()
+}
+
+// splitPluginVersion splits a 'pkg@version' plugin into its package and version
+func splitPluginVersion(pluginPkg string) (string, string) {
+	if idx := strings.LastIndex(pluginPkg, "@"); idx > 0 {
+		return pluginPkg[:idx], pluginPkg[idx+1:]
+	}
+	return pluginPkg, ""
+}
+
+// getPluginVersion returns the version of the module of the plugin package required by the rebuilt CLI
+func getPluginVersion(cliCmdPath, pluginPkg string) string {
+
+	cmd := exec.Command("go", "list", "-f", "{{if .Module}}{{.Module.Version}}{{end}}", pluginPkg)
+	cmd.Dir = cliCmdPath
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func addPlugin(cliCmdPath, pluginPkg, version string) (bool, error) {
+
+	pkg := pluginPkg
+	if version != "" {
+		pkg = pluginPkg + "@" + version
+	}
 
-	err := util.ExecCmd(exec.Command("go", "get", pluginPkg), cliCmdPath)
+	err := util.ExecCmd(exec.Command("go", "get", pkg), cliCmdPath)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var recordActivities []string
+var recordReplay []string
+var recordFixtures string
+
+func init() {
+	recordCmd.Flags().StringSliceVarP(&recordActivities, "activity", "a", nil, "activity ref or alias to record, all activities are recorded if not specified")
+	recordCmd.Flags().StringSliceVarP(&recordReplay, "replay", "r", nil, "activity ref or alias whose recorded outputs are replayed")
+	recordCmd.Flags().StringVarP(&recordFixtures, "fixtures", "", "", "directory of the recorded fixtures, defaults to 'fixtures' in the project")
+	rootCmd.AddCommand(recordCmd)
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record [flags]",
+	Short: "build the app recording or replaying activity fixtures",
+	Long:  `Builds the app with the activities wrapped to record their inputs and outputs, or to replay recorded outputs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		recordOptions := api.RecordOptions{FixturesDir: recordFixtures, Record: recordActivities, Replay: recordReplay}
+
+		err := api.BuildRecordingProject(common.CurrentProject(), recordOptions, common.BuildOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building recording app: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
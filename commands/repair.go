@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/spf13/cobra"
+)
+
+var repairDryRun bool
+
+func init() {
+	repairCmd.Flags().BoolVarP(&repairDryRun, "dry-run", "", false, "only report the problems found and the repairs")
+	rootCmd.AddCommand(repairCmd)
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair [flags]",
+	Short: "repair the project",
+	Long:  "Detects the inconsistencies left by interrupted commands and restores a consistent project",
+	Args:  cobra.NoArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// the project isn't validated, repairing it is the purpose of the command
+		api.SetVerbose(verbose)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		currentDir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error determining working directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		_, err = api.RepairProject(api.NewAppProject(currentDir), repairDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error repairing project: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
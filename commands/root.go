@@ -30,6 +30,7 @@ var rootCmd = &cobra.Command{
 
 func Initialize(version string) {
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "configuration profile to use")
 
 	if len(version) > 0 {
 		rootCmd.Version = version // use version hardcoded by a "go generate" command
@@ -37,6 +38,7 @@ func Initialize(version string) {
 		_, rootCmd.Version, _ = util.GetCLIInfo() // guess version from sources in $GOPATH/src
 	}
 
+	common.SetCLIVersion(rootCmd.Version)
 	rootCmd.SetVersionTemplate(VersionTpl)
 
 	//Get the list of commands from the registry of commands and add.
@@ -46,6 +48,8 @@ func Initialize(version string) {
 
 		rootCmd.AddCommand(command)
 	}
+
+	addExternalPlugins()
 }
 
 func Execute() {
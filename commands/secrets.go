@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var secretsDryRun bool
+
+func init() {
+	secretsExternalizeCmd.Flags().BoolVarP(&secretsDryRun, "dry-run", "", false, "only report the secrets that would be externalized")
+	secretsCmd.AddCommand(secretsScanCmd)
+	secretsCmd.AddCommand(secretsExternalizeCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "manage secrets of the descriptors",
+	Long:  "Detects the likely secrets of " + fJsonFile + " and engine.json and moves them to the environment",
+}
+
+var secretsScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "scan the descriptors for secrets",
+	Long:  "Reports the values of " + fJsonFile + " and engine.json that are likely secrets, the command fails if any is found",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		findings, err := api.ScanSecrets(common.CurrentProject())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(findings) == 0 {
+			fmt.Println("No secrets found")
+			return
+		}
+
+		for _, finding := range findings {
+			fmt.Printf("%s%s: %s (%s)\n", finding.File, finding.Path, finding.MaskedValue(), finding.Reason)
+		}
+		os.Exit(1)
+	},
+}
+
+var secretsExternalizeCmd = &cobra.Command{
+	Use:   "externalize [flags]",
+	Short: "move the secrets to the environment",
+	Long:  "Replaces the secrets of " + fJsonFile + " with references to app properties read from the environment variables of the same name",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		externalized, notExternalized, err := api.ExternalizeSecrets(common.CurrentProject(), secretsDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error externalizing secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		verb := "Externalized"
+		if secretsDryRun {
+			verb = "Would externalize"
+		}
+
+		for _, secret := range externalized {
+			fmt.Printf("%s %s%s as property '%s' (%s)\n", verb, secret.Finding.File, secret.Finding.Path, secret.Property, secret.Finding.Reason)
+		}
+		for _, finding := range notExternalized {
+			fmt.Fprintf(os.Stderr, "Warning: unable to externalize %s%s (%s), move it to the environment manually\n", finding.File, finding.Path, finding.Reason)
+		}
+
+		if len(externalized) == 0 {
+			fmt.Println("No secrets to externalize")
+			return
+		}
+
+		if !secretsDryRun {
+			fmt.Println("The previous values have been removed from " + fJsonFile + " and saved to " + api.FileSecretsEnv + ", which git ignores. " +
+				"Set the environment variables of the properties, for example with 'set -a; . ./" + api.FileSecretsEnv + "; set +a', and run the app with AIFLOW_APP_PROPS_ENV=auto")
+		}
+	},
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	envServeToken = "AIFLOW_SERVE_TOKEN"
+)
+
+var serveAddr string
+var serveToken string
+var serveOrigins []string
+var serveHosts []string
+
+func init() {
+	serveCmd.Flags().StringVarP(&serveAddr, "addr", "", "localhost:7450", "address the API listens on")
+	serveCmd.Flags().StringVarP(&serveToken, "token", "", "", "bearer token required by the requests, "+envServeToken+" by default, generated if none")
+	serveCmd.Flags().StringSliceVarP(&serveOrigins, "allow-origin", "", nil, "origins of the web pages allowed to call the API")
+	serveCmd.Flags().StringSliceVarP(&serveHosts, "allow-host", "", nil, "host names the API may be called with besides the loopback ones")
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [flags]",
+	Short: "serve the CLI API over HTTP",
+	Long:  "Serves the operations on projects as a local HTTP+JSON API, the progress of builds is streamed with server-sent events",
+	Args:  cobra.NoArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		api.SetVerbose(verbose)
+		common.SetVerbose(verbose)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if serveToken == "" {
+			serveToken = os.Getenv(envServeToken)
+		}
+
+		generated := false
+		if serveToken == "" {
+			var err error
+			serveToken, err = generateServeToken()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating the token: %v\n", err)
+				os.Exit(1)
+			}
+			generated = true
+		}
+
+		server := &http.Server{
+			Addr:              serveAddr,
+			Handler:           api.NewServer(api.ServerOptions{Token: serveToken, AllowedOrigins: serveOrigins, AllowedHosts: serveHosts}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Printf("Serving the AIflow API on http://%s\n", serveAddr)
+		if generated {
+			fmt.Printf("Token: %s\n", serveToken)
+		}
+		if err := server.ListenAndServe(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving the API: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// generateServeToken returns a random token for the requests of the API
+func generateServeToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var testJUnitFile string
+
+func init() {
+	testCmd.Flags().StringVarP(&testJUnitFile, "junit", "", "", "write the JUnit XML report to the specified file instead of stdout")
+	rootCmd.AddCommand(testCmd)
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [flags] [testFile...]",
+	Short: "run flow unit tests",
+	Long:  `Runs the flow test cases in the 'tests' directory, without starting the app's triggers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		cases, err := api.LoadFlowTestCases(common.CurrentProject(), args...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading test cases: %v\n", err)
+			os.Exit(1)
+		}
+
+		report, err := api.RunFlowTests(common.CurrentProject(), cases)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running flow tests: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := os.Stdout
+		if testJUnitFile != "" {
+			out, err = os.Create(testJUnitFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating JUnit report: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		err = api.WriteJUnitReport(out, report)
+		if testJUnitFile != "" {
+			_ = out.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+
+		api.PrintFlowTestSummary(os.Stderr, report)
+
+		if report.Failed() > 0 {
+			os.Exit(1)
+		}
+	},
+}
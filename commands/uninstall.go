@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(uninstallCmd)
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall <contribution|dependency>...",
+	Short: "uninstall a AIflow contribution/dependency",
+	Long:  "Removes a AIflow contribution or dependency from the app imports",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		for _, pkg := range args {
+			err := api.UninstallPackage(common.CurrentProject(), pkg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error uninstalling contribution/dependency: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
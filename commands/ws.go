@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/r2d2-ai/aiflow-cli/api"
+	"github.com/r2d2-ai/aiflow-cli/common"
+	"github.com/spf13/cobra"
+)
+
+var wsFile string
+var wsJobs int
+var wsEnv string
+
+func init() {
+	wsCmd.PersistentFlags().StringVarP(&wsFile, "workspace", "w", "", "the "+api.FileWorkspace+" file, by default the closest one in the working directory or its parents")
+	wsCmd.PersistentFlags().IntVarP(&wsJobs, "jobs", "j", 0, "number of apps processed concurrently, one per CPU by default")
+
+	wsBuildCmd.Flags().BoolVarP(&buildOptimize, "optimize", "o", false, "optimize build")
+	wsBuildCmd.Flags().BoolVarP(&buildEmbed, "embed", "e", false, "embed configuration in binary")
+	wsBuildCmd.Flags().StringSliceVarP(&buildTargets, "target", "t", nil, "build for the GOOS/GOARCH targets (ex. linux/amd64)")
+	wsBuildCmd.Flags().StringVarP(&buildEnv, "env", "", "", "embed the configuration with the overlays of the environment")
+	wsBuildCmd.Flags().BoolVarP(&buildCompress, "compress", "", false, "embed the configuration gzipped")
+	wsBuildCmd.Flags().BoolVarP(&buildEncrypt, "encrypt", "", false, "embed the configuration encrypted with the key of "+api.EnvConfigKey)
+	wsBuildCmd.Flags().BoolVarP(&buildReproducible, "reproducible", "", false, "build without the paths, build ids and time of the build machine")
+	wsBuildCmd.Flags().BoolVarP(&buildForce, "force", "", false, "build even if the executables are up to date")
+	wsBuildCmd.Flags().BoolVarP(&buildStamp, "stamp", "", false, "stamp the build information into the app, printed by the app with --version")
+
+	wsValidateCmd.Flags().StringVarP(&wsEnv, "env", "", "", "validate the descriptors with the overlays of the environment")
+
+	wsCmd.AddCommand(wsBuildCmd)
+	wsCmd.AddCommand(wsSyncCmd)
+	wsCmd.AddCommand(wsValidateCmd)
+	wsCmd.AddCommand(wsOutdatedCmd)
+	rootCmd.AddCommand(wsCmd)
+}
+
+var wsCmd = &cobra.Command{
+	Use:   "ws",
+	Short: "manage the apps of a workspace",
+	Long:  "Builds and maintains the apps listed by an " + api.FileWorkspace + " file, processing the apps concurrently",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		api.SetVerbose(verbose)
+		common.SetVerbose(verbose)
+	},
+}
+
+var wsBuildCmd = &cobra.Command{
+	Use:   "build [flags]",
+	Short: "build the apps of the workspace",
+	Long:  "Downloads the modules of the apps of the workspace and builds the apps concurrently",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		options := buildOptions(cmd)
+		options.Reproducible = buildReproducible
+		options.Force = buildForce
+
+		reportWorkspace(api.BuildWorkspace(loadWorkspace(), wsJobs, options))
+	},
+}
+
+var wsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync the Go imports of the apps of the workspace",
+	Long:  "Synchronizes the Go imports of the apps of the workspace with the imports of their descriptors",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		reportWorkspace(api.SyncWorkspace(loadWorkspace(), wsJobs))
+	},
+}
+
+var wsValidateCmd = &cobra.Command{
+	Use:   "validate [flags]",
+	Short: "validate the apps of the workspace",
+	Long:  "Validates the projects of the apps of the workspace and their descriptors",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		reportWorkspace(api.ValidateWorkspace(loadWorkspace(), wsJobs, wsEnv))
+	},
+}
+
+var wsOutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "list the outdated contributions of the apps of the workspace",
+	Long:  "Lists the modules providing the contributions of the apps of the workspace for which a newer version is available",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		reportWorkspace(api.OutdatedWorkspace(loadWorkspace(), wsJobs))
+	},
+}
+
+// loadWorkspace reads the workspace file of the --workspace flag or the closest one of the working directory
+func loadWorkspace() *api.Workspace {
+
+	var ws *api.Workspace
+	var err error
+
+	if wsFile != "" {
+		ws, err = api.ReadWorkspace(wsFile)
+	} else {
+		var currentDir string
+		currentDir, err = os.Getwd()
+		if err == nil {
+			ws, err = api.FindWorkspace(currentDir)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading workspace: %v\n", err)
+		os.Exit(1)
+	}
+
+	return ws
+}
+
+func reportWorkspace(results []*api.WorkspaceResult) {
+	if api.PrintWorkspaceReport(os.Stdout, results) > 0 {
+		os.Exit(1)
+	}
+}
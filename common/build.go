@@ -1,15 +1,54 @@
 package common
 
+import "time"
+
 type BuildOptions struct {
-	OptimizeImports bool
-	EmbedConfig     bool
-	Shim            string
+	OptimizeImports bool     `json:"optimize,omitempty"`
+	EmbedConfig     bool     `json:"embed,omitempty"`
+	Shim            string   `json:"shim,omitempty"`
+	Targets         []string `json:"targets,omitempty"`      // GOOS/GOARCH to build for, the current platform if empty
+	Env             string   `json:"env,omitempty"`          // environment whose overlays are applied to the embedded configuration
+	CompressConfig  bool     `json:"compress,omitempty"`     // gzip the embedded configuration
+	EncryptConfig   bool     `json:"encrypt,omitempty"`      // encrypt the embedded configuration with the key of the AIFLOW_CONFIG_KEY environment variable
+	Reproducible    bool     `json:"reproducible,omitempty"` // build without the paths, build ids and time of the build machine
+	Force           bool     `json:"force,omitempty"`        // build even if the executables are up to date
+	StampInfo       bool     `json:"stamp,omitempty"`        // stamp the build information into the app, printed by the app with --version
+}
+
+// BuildResult describes the outcome of a build
+type BuildResult struct {
+	Executables    []string      `json:"executables"`              // files written to the bin directory
+	Targets        []string      `json:"targets"`                  // GOOS/GOARCH the app was built for
+	Options        BuildOptions  `json:"options"`                  // options the build was requested with
+	Duration       time.Duration `json:"duration"`                 // time taken by the build, in nanoseconds
+	RemovedImports []string      `json:"removedImports,omitempty"` // Go imports removed when optimizing imports
+	EmbeddedConfig bool          `json:"embeddedConfig"`           // whether the app configuration was embedded
+	Info           *BuildInfo    `json:"info,omitempty"`           // build information, only collected with StampInfo or when hooks or result post processors are registered
+	Cached         bool          `json:"cached"`                   // whether the executables were up to date and the build skipped
+}
+
+// BuildInfo is the build information stamped into the app, it is printed by the app with --version
+type BuildInfo struct {
+	AppName       string    `json:"appName"`
+	AppVersion    string    `json:"appVersion"`
+	Revision      string    `json:"revision,omitempty"` // VCS revision of the project
+	Modified      bool      `json:"modified,omitempty"` // whether the project had uncommitted changes
+	BuildTime     time.Time `json:"buildTime"`
+	CLIVersion    string    `json:"cliVersion,omitempty"`
+	Contributions []string  `json:"contributions,omitempty"` // contributions with their resolved versions
 }
 
 type Builder interface {
 	Build(project AppProject) error
 }
 
+// ExecutableBuilder is a Builder that returns the executables it built, when the builder used implements it
+// BuildExecutables is called instead of Build
+type ExecutableBuilder interface {
+	Builder
+	BuildExecutables(project AppProject) ([]string, error)
+}
+
 type BuildPreProcessor interface {
 	DoPreProcessing(project AppProject, options BuildOptions) error
 }
@@ -18,6 +57,13 @@ type BuildPostProcessor interface {
 	DoPostProcessing(project AppProject) error
 }
 
+// BuildResultPostProcessor is a BuildPostProcessor that receives the result of the build, when a registered
+// post processor implements it DoPostProcessingWithResult is called instead of DoPostProcessing
+type BuildResultPostProcessor interface {
+	BuildPostProcessor
+	DoPostProcessingWithResult(project AppProject, result *BuildResult) error
+}
+
 var buildPreProcessors []BuildPreProcessor
 var buildPostProcessors []BuildPostProcessor
 
@@ -36,5 +82,3 @@ func RegisterBuildPostProcessor(processor BuildPostProcessor) {
 func BuildPostProcessors() []BuildPostProcessor {
 	return buildPostProcessors
 }
-
-
@@ -0,0 +1,422 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	fileUserConfig    = "config.yaml"
+	FileProjectConfig = ".aiflow.yaml"
+
+	// EnvProfile selects the config profile when --profile isn't specified
+	EnvProfile = "AIFLOW_PROFILE"
+
+	keyProfiles = "profiles"
+)
+
+// config settings
+const (
+	ConfigCoreVersion   = "coreVersion"
+	ConfigBuildShim     = "build.shim"
+	ConfigBuildOptimize = "build.optimize"
+	ConfigBuildEmbed    = "build.embed"
+	ConfigBuildTargets  = "build.targets"
+	ConfigBuildEnv      = "build.env"
+	ConfigBuildCompress = "build.compress"
+	ConfigGoProxy       = "goproxy"
+	ConfigGoPrivate     = "goprivate"
+	ConfigPluginDirs    = "pluginDirs"
+	ConfigOutput        = "output"
+)
+
+type configKind int
+
+const (
+	kindString configKind = iota
+	kindBool
+	kindList
+)
+
+type configSetting struct {
+	kind configKind
+	env  string
+}
+
+var configSettings = map[string]configSetting{
+	ConfigCoreVersion:   {kindString, "AIFLOW_CORE_VERSION"},
+	ConfigBuildShim:     {kindString, "AIFLOW_BUILD_SHIM"},
+	ConfigBuildOptimize: {kindBool, "AIFLOW_BUILD_OPTIMIZE"},
+	ConfigBuildEmbed:    {kindBool, "AIFLOW_BUILD_EMBED"},
+	ConfigBuildTargets:  {kindList, "AIFLOW_BUILD_TARGETS"},
+	ConfigBuildEnv:      {kindString, "AIFLOW_BUILD_ENV"},
+	ConfigBuildCompress: {kindBool, "AIFLOW_BUILD_COMPRESS"},
+	ConfigGoProxy:       {kindString, "GOPROXY"},
+	ConfigGoPrivate:     {kindString, "GOPRIVATE"},
+	ConfigPluginDirs:    {kindList, "AIFLOW_PLUGIN_DIRS"},
+	ConfigOutput:        {kindString, "AIFLOW_OUTPUT"},
+}
+
+// ConfigKeys returns the names of the supported settings
+func ConfigKeys() []string {
+	var keys []string
+	for key := range configSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigEnvVar returns the environment variable that overrides the setting
+func ConfigEnvVar(key string) string {
+	return configSettings[key].env
+}
+
+// GetUserConfigFile returns the path of the user config file
+func GetUserConfigFile() string {
+	return filepath.Join(GetUserConfigDir(), fileUserConfig)
+}
+
+// ConfigFile is a user or project config file, settings are addressed with dotted keys
+// and profiles override the settings of the file when selected
+type ConfigFile struct {
+	path     string
+	settings map[string]interface{}
+}
+
+// LoadConfigFile loads a config file, an empty config is returned if it doesn't exist
+func LoadConfigFile(path string) (*ConfigFile, error) {
+
+	cf := &ConfigFile{path: path, settings: make(map[string]interface{})}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cf, nil
+		}
+		return nil, err
+	}
+
+	var settings map[string]interface{}
+	err = yaml.Unmarshal(buf, &settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %v", path, err)
+	}
+
+	if settings != nil {
+		cf.settings = normalizeYaml(settings).(map[string]interface{})
+	}
+
+	return cf, nil
+}
+
+// Path returns the path of the config file
+func (cf *ConfigFile) Path() string {
+	return cf.path
+}
+
+// Get returns the value of the setting, from the profile if specified
+func (cf *ConfigFile) Get(profile, key string) (interface{}, bool) {
+	return lookupKey(cf.section(profile, false), key)
+}
+
+// Set sets the setting in the profile if specified, the value is validated against the setting's kind
+func (cf *ConfigFile) Set(profile, key, value string) error {
+
+	setting, ok := configSettings[key]
+	if !ok {
+		return fmt.Errorf("unknown setting '%s'", key)
+	}
+
+	val, err := parseConfigValue(setting.kind, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for '%s': %v", key, err)
+	}
+
+	section := cf.section(profile, true)
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := section[part].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			section[part] = sub
+		}
+		section = sub
+	}
+	section[parts[len(parts)-1]] = val
+
+	return nil
+}
+
+// Save writes the config file
+func (cf *ConfigFile) Save() error {
+
+	buf, err := yaml.Marshal(cf.settings)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cf.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cf.path, buf, 0644)
+}
+
+func (cf *ConfigFile) section(profile string, create bool) map[string]interface{} {
+	if profile == "" {
+		return cf.settings
+	}
+
+	profiles, ok := cf.settings[keyProfiles].(map[string]interface{})
+	if !ok {
+		if !create {
+			return nil
+		}
+		profiles = make(map[string]interface{})
+		cf.settings[keyProfiles] = profiles
+	}
+
+	section, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		if !create {
+			return nil
+		}
+		section = make(map[string]interface{})
+		profiles[profile] = section
+	}
+
+	return section
+}
+
+// ConfigValue is a resolved setting and where it was set
+type ConfigValue struct {
+	Key    string
+	Value  interface{}
+	Source string
+}
+
+func (v *ConfigValue) String() string {
+	return formatConfigValue(v.Value)
+}
+
+// Config is the resolved configuration, environment variables take precedence over the
+// project config file, which takes precedence over the user config file
+type Config struct {
+	Profile string
+	values  map[string]*ConfigValue
+}
+
+// LoadConfig resolves the configuration for the project directory and profile, the project
+// directory and profile are optional
+func LoadConfig(projectDir, profile string) (*Config, error) {
+
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+
+	files := []string{GetUserConfigFile()}
+	if projectDir != "" {
+		files = append(files, filepath.Join(projectDir, FileProjectConfig))
+	}
+
+	var configFiles []*ConfigFile
+	for _, file := range files {
+		cf, err := LoadConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		configFiles = append(configFiles, cf)
+	}
+
+	return resolveConfig(configFiles, profile)
+}
+
+func resolveConfig(configFiles []*ConfigFile, profile string) (*Config, error) {
+
+	config := &Config{Profile: profile, values: make(map[string]*ConfigValue)}
+
+	profileFound := profile == ""
+	for _, cf := range configFiles {
+		if profile != "" && cf.section(profile, false) != nil {
+			profileFound = true
+		}
+
+		for key := range configSettings {
+			if val, ok := cf.Get("", key); ok {
+				config.values[key] = &ConfigValue{Key: key, Value: val, Source: cf.path}
+			}
+			if profile == "" {
+				continue
+			}
+			if val, ok := cf.Get(profile, key); ok {
+				config.values[key] = &ConfigValue{Key: key, Value: val, Source: cf.path + " (" + profile + ")"}
+			}
+		}
+	}
+
+	if !profileFound {
+		return nil, fmt.Errorf("profile '%s' not found", profile)
+	}
+
+	for key, setting := range configSettings {
+		envVal, ok := os.LookupEnv(setting.env)
+		if !ok {
+			continue
+		}
+		val, err := parseConfigValue(setting.kind, envVal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", setting.env, err)
+		}
+		config.values[key] = &ConfigValue{Key: key, Value: val, Source: "env " + setting.env}
+	}
+
+	return config, nil
+}
+
+// Get returns the setting's value formatted as a string
+func (c *Config) Get(key string) (string, bool) {
+	val, ok := c.values[key]
+	if !ok {
+		return "", false
+	}
+
+	return formatConfigValue(val.Value), true
+}
+
+// GetBool returns the setting's value as a bool
+func (c *Config) GetBool(key string) (bool, bool) {
+	val, ok := c.values[key]
+	if !ok {
+		return false, false
+	}
+
+	switch t := val.Value.(type) {
+	case bool:
+		return t, true
+	case string:
+		b, err := strconv.ParseBool(t)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+// GetList returns the setting's value as a list
+func (c *Config) GetList(key string) ([]string, bool) {
+	val, ok := c.values[key]
+	if !ok {
+		return nil, false
+	}
+
+	switch t := val.Value.(type) {
+	case []interface{}:
+		var list []string
+		for _, item := range t {
+			list = append(list, fmt.Sprintf("%v", item))
+		}
+		return list, true
+	case string:
+		return splitList(t), true
+	default:
+		return nil, false
+	}
+}
+
+// Values returns the resolved settings sorted by key
+func (c *Config) Values() []*ConfigValue {
+	var values []*ConfigValue
+	for _, key := range ConfigKeys() {
+		if val, ok := c.values[key]; ok {
+			values = append(values, val)
+		}
+	}
+	return values
+}
+
+func parseConfigValue(kind configKind, value string) (interface{}, error) {
+	switch kind {
+	case kindBool:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case kindList:
+		var list []interface{}
+		for _, item := range splitList(value) {
+			list = append(list, item)
+		}
+		return list, nil
+	default:
+		return value, nil
+	}
+}
+
+func formatConfigValue(val interface{}) string {
+	if list, ok := val.([]interface{}); ok {
+		var items []string
+		for _, item := range list {
+			items = append(items, fmt.Sprintf("%v", item))
+		}
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprintf("%v", val)
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func lookupKey(section map[string]interface{}, key string) (interface{}, bool) {
+	if section == nil {
+		return nil, false
+	}
+
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := section[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		section = sub
+	}
+
+	val, ok := section[parts[len(parts)-1]]
+	return val, ok
+}
+
+// normalizeYaml converts the map[interface{}]interface{} values produced by the yaml decoder
+func normalizeYaml(val interface{}) interface{} {
+	switch t := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprintf("%v", k)] = normalizeYaml(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range t {
+			t[k] = normalizeYaml(v)
+		}
+		return t
+	case []interface{}:
+		for i, v := range t {
+			t[i] = normalizeYaml(v)
+		}
+		return t
+	default:
+		return val
+	}
+}
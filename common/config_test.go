@@ -0,0 +1,97 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	userFile := filepath.Join(tempDir, "config.yaml")
+	err = ioutil.WriteFile(userFile, []byte(`
+coreVersion: v1.0.0
+output: json
+build:
+  optimize: true
+profiles:
+  prod:
+    build:
+      embed: true
+      targets: [linux/amd64, linux/arm64]
+`), 0644)
+	assert.Nil(t, err)
+
+	projectFile := filepath.Join(tempDir, FileProjectConfig)
+	err = ioutil.WriteFile(projectFile, []byte(`
+coreVersion: master
+`), 0644)
+	assert.Nil(t, err)
+
+	userCfg, err := LoadConfigFile(userFile)
+	assert.Nil(t, err)
+	projectCfg, err := LoadConfigFile(projectFile)
+	assert.Nil(t, err)
+
+	config, err := resolveConfig([]*ConfigFile{userCfg, projectCfg}, "")
+	assert.Nil(t, err)
+
+	val, _ := config.Get(ConfigCoreVersion)
+	assert.Equal(t, "master", val)
+	optimize, ok := config.GetBool(ConfigBuildOptimize)
+	assert.True(t, ok)
+	assert.True(t, optimize)
+	_, ok = config.GetBool(ConfigBuildEmbed)
+	assert.False(t, ok)
+
+	config, err = resolveConfig([]*ConfigFile{userCfg, projectCfg}, "prod")
+	assert.Nil(t, err)
+	embed, _ := config.GetBool(ConfigBuildEmbed)
+	assert.True(t, embed)
+	targets, _ := config.GetList(ConfigBuildTargets)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, targets)
+
+	_ = os.Setenv("AIFLOW_OUTPUT", "text")
+	defer os.Unsetenv("AIFLOW_OUTPUT")
+
+	config, err = resolveConfig([]*ConfigFile{userCfg, projectCfg}, "")
+	assert.Nil(t, err)
+	val, _ = config.Get(ConfigOutput)
+	assert.Equal(t, "text", val)
+
+	_, err = resolveConfig([]*ConfigFile{userCfg, projectCfg}, "missing")
+	assert.NotNil(t, err)
+}
+
+func TestConfigFileSet(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	cf, err := LoadConfigFile(filepath.Join(tempDir, "config.yaml"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, cf.Set("", ConfigBuildShim, "lambda"))
+	assert.Nil(t, cf.Set("dev", ConfigBuildTargets, "linux/amd64,darwin/arm64"))
+	assert.NotNil(t, cf.Set("", ConfigBuildEmbed, "maybe"))
+	assert.NotNil(t, cf.Set("", "unknown", "value"))
+	assert.Nil(t, cf.Save())
+
+	cf, err = LoadConfigFile(filepath.Join(tempDir, "config.yaml"))
+	assert.Nil(t, err)
+
+	val, ok := cf.Get("", ConfigBuildShim)
+	assert.True(t, ok)
+	assert.Equal(t, "lambda", val)
+	val, ok = cf.Get("dev", ConfigBuildTargets)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"linux/amd64", "darwin/arm64"}, val)
+}
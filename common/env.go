@@ -4,22 +4,32 @@ import (
 	"github.com/r2d2-ai/aiflow-cli/util"
 )
 
-var verbose = false
-var appProject AppProject
+var cliVersion string
 
+// SetVerbose sets the verbosity of the default session and of the commands run by util
 func SetVerbose(enable bool) {
-	verbose = enable
+	defaultSession.SetVerbose(enable)
 	util.SetVerbose(enable)
 }
 
 func Verbose() bool {
-	return verbose
+	return defaultSession.Verbose()
 }
 
+// CurrentProject returns the current project of the default session
 func CurrentProject() AppProject {
-	return appProject
+	return defaultSession.CurrentProject()
 }
 
 func SetCurrentProject(project AppProject) {
-	appProject = project
+	defaultSession.SetCurrentProject(project)
+}
+
+// CLIVersion returns the version of the CLI
+func CLIVersion() string {
+	return cliVersion
+}
+
+func SetCLIVersion(version string) {
+	cliVersion = version
 }
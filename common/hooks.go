@@ -0,0 +1,144 @@
+package common
+
+import (
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// HookPhase indicates whether an event is fired before or after the operation
+type HookPhase int
+
+const (
+	// PhasePre events are fired before the operation, a hook returning an error vetoes the operation
+	PhasePre HookPhase = iota
+	// PhasePost events are fired after the operation completed successfully
+	PhasePost
+)
+
+func (p HookPhase) String() string {
+	if p == PhasePost {
+		return "post"
+	}
+	return "pre"
+}
+
+// Event is a project lifecycle event passed to the registered hooks
+type Event interface {
+	EventName() string
+	EventPhase() HookPhase
+}
+
+// Hook handles project lifecycle events, the hook type switches on the events it is interested in
+type Hook interface {
+	HandleEvent(event Event) error
+}
+
+// HookFunc adapts a function to a Hook
+type HookFunc func(event Event) error
+
+func (f HookFunc) HandleEvent(event Event) error {
+	return f(event)
+}
+
+// CreateProjectEvent is fired when an app project is created, Project is only set after creation
+type CreateProjectEvent struct {
+	Phase    HookPhase
+	BasePath string
+	AppName  string
+	AppJson  string
+	Project  AppProject
+}
+
+func (e *CreateProjectEvent) EventName() string     { return "create" }
+func (e *CreateProjectEvent) EventPhase() HookPhase { return e.Phase }
+
+// InstallEvent is fired when a contribution is installed, Descriptor is only set after installation
+type InstallEvent struct {
+	Phase      HookPhase
+	Project    AppProject
+	Import     util.Import
+	Descriptor *util.AIflowContribDescriptor
+}
+
+func (e *InstallEvent) EventName() string     { return "install" }
+func (e *InstallEvent) EventPhase() HookPhase { return e.Phase }
+
+// UninstallEvent is fired when a contribution is uninstalled
+type UninstallEvent struct {
+	Phase   HookPhase
+	Project AppProject
+	Import  util.Import
+}
+
+func (e *UninstallEvent) EventName() string     { return "uninstall" }
+func (e *UninstallEvent) EventPhase() HookPhase { return e.Phase }
+
+// UpdateEvent is fired when a contribution or dependency is updated
+type UpdateEvent struct {
+	Phase   HookPhase
+	Project AppProject
+	Pkg     string
+}
+
+func (e *UpdateEvent) EventName() string     { return "update" }
+func (e *UpdateEvent) EventPhase() HookPhase { return e.Phase }
+
+// SyncImportsEvent is fired when the Go imports are synchronized with the app imports
+type SyncImportsEvent struct {
+	Phase   HookPhase
+	Project AppProject
+	Added   []util.Import
+	Removed []string
+}
+
+func (e *SyncImportsEvent) EventName() string     { return "sync" }
+func (e *SyncImportsEvent) EventPhase() HookPhase { return e.Phase }
+
+// BuildEvent is fired when the app is built, Executables and Result are only set after the build
+type BuildEvent struct {
+	Phase       HookPhase
+	Project     AppProject
+	Options     BuildOptions
+	Executables []string
+	Result      *BuildResult
+}
+
+func (e *BuildEvent) EventName() string     { return "build" }
+func (e *BuildEvent) EventPhase() HookPhase { return e.Phase }
+
+// ValidateEvent is fired when a project is validated, a hook returning an error rejects the app descriptor
+type ValidateEvent struct {
+	Project    AppProject
+	Descriptor *util.AIflowAppDescriptor
+}
+
+func (e *ValidateEvent) EventName() string     { return "validate" }
+func (e *ValidateEvent) EventPhase() HookPhase { return PhasePre }
+
+var hooks []Hook
+
+func RegisterHook(hook Hook) {
+	hooks = append(hooks, hook)
+}
+
+func Hooks() []Hook {
+	tmp := make([]Hook, len(hooks))
+	copy(tmp, hooks)
+
+	return tmp
+}
+
+// SetHooks replaces the registered hooks, e.g. to restore the ones returned by Hooks
+func SetHooks(registered []Hook) {
+	hooks = make([]Hook, len(registered))
+	copy(hooks, registered)
+}
+
+// FireEvent passes the event to the registered hooks in registration order, stopping at the first error
+func FireEvent(event Event) error {
+	for _, hook := range hooks {
+		if err := hook.HandleEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFireEvent(t *testing.T) {
+
+	registered := hooks
+	defer func() { hooks = registered }()
+	hooks = nil
+
+	var handled []string
+	RegisterHook(HookFunc(func(event Event) error {
+		handled = append(handled, "first "+event.EventName()+" "+event.EventPhase().String())
+		if _, ok := event.(*UninstallEvent); ok && event.EventPhase() == PhasePre {
+			return errors.New("vetoed")
+		}
+		return nil
+	}))
+	RegisterHook(HookFunc(func(event Event) error {
+		handled = append(handled, "second "+event.EventName()+" "+event.EventPhase().String())
+		return nil
+	}))
+	assert.Len(t, Hooks(), 2)
+
+	assert.Nil(t, FireEvent(&UpdateEvent{Phase: PhasePost, Pkg: "github.com/r2d2-ai/aiflow"}))
+	assert.Equal(t, []string{"first update post", "second update post"}, handled)
+
+	handled = nil
+	err := FireEvent(&UninstallEvent{Phase: PhasePre})
+	assert.EqualError(t, err, "vetoed")
+	assert.Equal(t, []string{"first uninstall pre"}, handled)
+
+	assert.Equal(t, PhasePre, (&ValidateEvent{}).EventPhase())
+}
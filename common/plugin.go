@@ -1,9 +1,29 @@
 package common
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
+const (
+	// ExternalPluginPrefix is the prefix of the executables discovered as external plugins
+	ExternalPluginPrefix = "AIflow-"
+
+	// EnvPluginsDir overrides the directory searched for external plugins
+	EnvPluginsDir = "AIFLOW_PLUGINS_DIR"
+
+	// environment variables passed to external plugins
+	EnvPluginProjectDir = "AIFLOW_PROJECT_DIR"
+	EnvPluginCLIVersion = "AIFLOW_CLI_VERSION"
+	EnvPluginVerbose    = "AIFLOW_VERBOSE"
+)
+
 var commands []*cobra.Command
 var pluginPkgs []string
 
@@ -19,6 +39,87 @@ func GetPlugins() []*cobra.Command {
 	return tmp
 }
 
-func GetPluginPkgs() []string{
+func GetPluginPkgs() []string {
 	return pluginPkgs
 }
+
+// ExternalPlugin is an 'AIflow-<name>' executable providing the '<name>' command
+type ExternalPlugin struct {
+	Name string
+	Path string
+}
+
+// GetPluginsDir returns the directory searched for external plugins before the PATH
+func GetPluginsDir() string {
+	if dir := os.Getenv(EnvPluginsDir); dir != "" {
+		return dir
+	}
+
+	configDir := GetUserConfigDir()
+	if configDir == "" {
+		return ""
+	}
+
+	return filepath.Join(configDir, "plugins")
+}
+
+// GetExternalPlugins finds the external plugin executables in the plugins directory, the additional plugin
+// directories and on the PATH, when a plugin is found in several directories the first one found is used
+func GetExternalPlugins(pluginDirs ...string) []*ExternalPlugin {
+
+	dirs := []string{GetPluginsDir()}
+	dirs = append(dirs, pluginDirs...)
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+
+	found := make(map[string]*ExternalPlugin)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			name, ok := externalPluginName(file)
+			if !ok {
+				continue
+			}
+			if _, exists := found[name]; !exists {
+				found[name] = &ExternalPlugin{Name: name, Path: filepath.Join(dir, file.Name())}
+			}
+		}
+	}
+
+	plugins := make([]*ExternalPlugin, 0, len(found))
+	for _, plugin := range found {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+
+	return plugins
+}
+
+func externalPluginName(file os.FileInfo) (string, bool) {
+
+	if file.IsDir() || !strings.HasPrefix(file.Name(), ExternalPluginPrefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(file.Name(), ExternalPluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !strings.EqualFold(ext, ".exe") && !strings.EqualFold(ext, ".bat") && !strings.EqualFold(ext, ".cmd") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	} else if file.Mode()&0111 == 0 {
+		return "", false
+	}
+
+	return name, name != ""
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExternalPlugins(t *testing.T) {
+
+	tempDir, err := ioutil.TempDir("", "plugins")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	exe := ""
+	if runtime.GOOS == "windows" {
+		exe = ".exe"
+	}
+
+	pluginsDir := filepath.Join(tempDir, "plugins")
+	configDir := filepath.Join(tempDir, "config")
+	pathDir := filepath.Join(tempDir, "bin")
+	files := map[string]os.FileMode{
+		filepath.Join(pluginsDir, "AIflow-hello"+exe): 0755,
+		filepath.Join(pluginsDir, "AIflow-notes.txt"): 0644, // not executable
+		filepath.Join(pluginsDir, "hello"+exe):        0755, // not prefixed
+		filepath.Join(pluginsDir, "AIflow-"+exe):      0755, // no name
+		filepath.Join(configDir, "AIflow-fmt"+exe):    0755,
+		filepath.Join(pathDir, "AIflow-hello"+exe):    0755, // shadowed by the plugins dir
+		filepath.Join(pathDir, "AIflow-lint"+exe):     0755,
+	}
+	for file, mode := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.Nil(t, ioutil.WriteFile(file, []byte("#!/bin/sh\n"), mode))
+	}
+	assert.Nil(t, os.MkdirAll(filepath.Join(pluginsDir, "AIflow-dir"), 0755))
+
+	defer os.Setenv(EnvPluginsDir, os.Getenv(EnvPluginsDir))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv(EnvPluginsDir, pluginsDir)
+	os.Setenv("PATH", pathDir)
+
+	// the plugins dir is searched first, then the configured dirs and the PATH
+	plugins := GetExternalPlugins(configDir)
+	assert.Equal(t, []*ExternalPlugin{
+		{Name: "fmt", Path: filepath.Join(configDir, "AIflow-fmt"+exe)},
+		{Name: "hello", Path: filepath.Join(pluginsDir, "AIflow-hello"+exe)},
+		{Name: "lint", Path: filepath.Join(pathDir, "AIflow-lint"+exe)},
+	}, plugins)
+}
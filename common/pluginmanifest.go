@@ -0,0 +1,131 @@
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	filePluginManifest       = "plugins.json"
+	filePluginManifestBackup = "plugins.json.bak"
+	dirPluginBackup          = "backup"
+)
+
+// PluginManifest records the plugins compiled into the CLI
+type PluginManifest struct {
+	Plugins []*PluginManifestEntry `json:"plugins"`
+}
+
+// PluginManifestEntry records the version of a plugin package and when it was installed
+type PluginManifestEntry struct {
+	Package   string    `json:"package"`
+	Version   string    `json:"version,omitempty"`
+	Installed time.Time `json:"installed"`
+}
+
+// GetUserConfigDir returns the directory of the user's CLI configuration
+func GetUserConfigDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(configDir, "aiflow")
+}
+
+// GetPluginBackupDir returns the directory where the previous CLI executable is kept
+func GetPluginBackupDir() string {
+	return filepath.Join(GetUserConfigDir(), dirPluginBackup)
+}
+
+// LoadPluginManifest loads the plugin manifest, an empty manifest is returned if it doesn't exist yet
+func LoadPluginManifest() (*PluginManifest, error) {
+	return loadPluginManifest(filepath.Join(GetUserConfigDir(), filePluginManifest))
+}
+
+// LoadPluginManifestBackup loads the plugin manifest saved with the backup of the CLI executable
+func LoadPluginManifestBackup() (*PluginManifest, error) {
+	return loadPluginManifest(filepath.Join(GetPluginBackupDir(), filePluginManifestBackup))
+}
+
+func loadPluginManifest(manifestFile string) (*PluginManifest, error) {
+
+	manifest := &PluginManifest{}
+
+	buf, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(buf, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Save saves the plugin manifest in the user config directory
+func (m *PluginManifest) Save() error {
+	return m.save(filepath.Join(GetUserConfigDir(), filePluginManifest))
+}
+
+// SaveBackup saves the plugin manifest alongside the backup of the CLI executable
+func (m *PluginManifest) SaveBackup() error {
+	return m.save(filepath.Join(GetPluginBackupDir(), filePluginManifestBackup))
+}
+
+func (m *PluginManifest) save(manifestFile string) error {
+
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(manifestFile), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(manifestFile, buf, 0644)
+}
+
+// Get returns the entry of the plugin package, or nil if it isn't in the manifest
+func (m *PluginManifest) Get(pluginPkg string) *PluginManifestEntry {
+	for _, entry := range m.Plugins {
+		if entry.Package == pluginPkg {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Set records the version of the plugin package
+func (m *PluginManifest) Set(pluginPkg, version string) {
+	entry := m.Get(pluginPkg)
+	if entry == nil {
+		entry = &PluginManifestEntry{Package: pluginPkg}
+		m.Plugins = append(m.Plugins, entry)
+	}
+
+	if entry.Version != version || entry.Installed.IsZero() {
+		entry.Version = version
+		entry.Installed = time.Now()
+	}
+}
+
+// Remove removes the plugin package from the manifest
+func (m *PluginManifest) Remove(pluginPkg string) {
+	for i, entry := range m.Plugins {
+		if entry.Package == pluginPkg {
+			m.Plugins = append(m.Plugins[:i], m.Plugins[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/r2d2-ai/aiflow-cli/util"
+)
+
+// Session is the state of a client of the API: its logging, environment, registry of import aliases and current
+// project. Sessions are independent of each other, the functions of the API use the session of the project they
+// operate on, the default session for the projects that aren't bound to one
+type Session struct {
+	mu      sync.RWMutex
+	verbose bool
+	out     io.Writer
+	errOut  io.Writer
+	env     map[string]string            // overrides of the environment of the process
+	aliases map[string]map[string]string // refs of the import aliases by contribution type
+	project AppProject
+}
+
+// SessionProject is implemented by the projects bound to a session
+type SessionProject interface {
+	Session() *Session
+}
+
+var defaultSession = NewSession()
+
+// NewSession returns a session logging to stdout, with its warnings and errors printed to stderr, with the environment
+// of the process
+func NewSession() *Session {
+	return &Session{
+		out:     os.Stdout,
+		errOut:  os.Stderr,
+		env:     make(map[string]string),
+		aliases: make(map[string]map[string]string),
+	}
+}
+
+// DefaultSession returns the session used by the CLI and by the projects that aren't bound to a session
+func DefaultSession() *Session {
+	return defaultSession
+}
+
+// ProjectSession returns the session of the project, the default session if the project isn't bound to one
+func ProjectSession(project AppProject) *Session {
+	if sp, ok := project.(SessionProject); ok && sp.Session() != nil {
+		return sp.Session()
+	}
+	return defaultSession
+}
+
+func (s *Session) SetVerbose(enable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verbose = enable
+}
+
+func (s *Session) Verbose() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.verbose
+}
+
+// SetOutput sets the writer of the messages logged by the session
+func (s *Session) SetOutput(out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.out = out
+}
+
+// Fork returns a new session with the verbosity, the output and the environment overrides of the session, its
+// aliases and current project aren't shared
+func (s *Session) Fork() *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fork := NewSession()
+	fork.verbose = s.verbose
+	fork.out = s.out
+	fork.errOut = s.errOut
+	for key, value := range s.env {
+		fork.env[key] = value
+	}
+
+	return fork
+}
+
+// SetErrOutput sets the writer of the warnings and errors of the session
+func (s *Session) SetErrOutput(errOut io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errOut = errOut
+}
+
+// Printf prints the message to the output of the session
+func (s *Session) Printf(format string, args ...interface{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fmt.Fprintf(s.out, format, args...)
+}
+
+// ErrPrintf prints the warning or error to the error output of the session
+func (s *Session) ErrPrintf(format string, args ...interface{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fmt.Fprintf(s.errOut, format, args...)
+}
+
+// ExecCmd runs the command in the working directory with the environment of the session unless the command has one,
+// the output of the command is printed to the outputs of the session when it is verbose
+func (s *Session) ExecCmd(cmd *exec.Cmd, workingDir string) error {
+	if cmd.Env == nil {
+		cmd.Env = s.Environ()
+	}
+
+	s.mu.RLock()
+	verbose, out, errOut := s.verbose, s.out, s.errOut
+	s.mu.RUnlock()
+
+	return util.ExecCmdOutput(cmd, workingDir, verbose, out, errOut)
+}
+
+// Logf prints the message to the output of the session when it is verbose
+func (s *Session) Logf(format string, args ...interface{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.verbose {
+		fmt.Fprintf(s.out, format, args...)
+	}
+}
+
+// Setenv overrides the environment variable of the process for the session
+func (s *Session) Setenv(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.env[key] = value
+}
+
+// LookupEnv returns the environment variable of the session, or of the process if the session doesn't override it
+func (s *Session) LookupEnv(key string) (string, bool) {
+	s.mu.RLock()
+	value, ok := s.env[key]
+	s.mu.RUnlock()
+
+	if ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
+
+func (s *Session) Getenv(key string) string {
+	value, _ := s.LookupEnv(key)
+	return value
+}
+
+// Environ returns the environment of the process with the overrides of the session, in the format of os.Environ
+func (s *Session) Environ() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	environ := os.Environ()
+	if len(s.env) == 0 {
+		return environ
+	}
+
+	var keys []string
+	for key := range s.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// the last value of a variable is the one used by os/exec
+	for _, key := range keys {
+		environ = append(environ, key+"="+s.env[key])
+	}
+
+	return environ
+}
+
+// GOOS returns the operating system the apps of the session are built for
+func (s *Session) GOOS() string {
+	if goos := s.Getenv("GOOS"); goos != "" {
+		return goos
+	}
+	return runtime.GOOS
+}
+
+// GOARCH returns the architecture the apps of the session are built for
+func (s *Session) GOARCH() string {
+	if goarch := s.Getenv("GOARCH"); goarch != "" {
+		return goarch
+	}
+	return runtime.GOARCH
+}
+
+// RegisterAlias registers the ref of an import alias of the contribution type
+func (s *Session) RegisterAlias(contribType string, alias, ref string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aliasToRefMap, exists := s.aliases[contribType]
+	if !exists {
+		aliasToRefMap = make(map[string]string)
+		s.aliases[contribType] = aliasToRefMap
+	}
+
+	aliasToRefMap[alias] = ref
+}
+
+// GetAliasRef returns the ref of an import alias of the contribution type, the alias may be prefixed with #
+func (s *Session) GetAliasRef(contribType string, alias string) (string, bool) {
+	if alias == "" {
+		return "", false
+	}
+
+	if alias[0] == '#' {
+		alias = alias[1:]
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, exists := s.aliases[contribType][alias]
+	return ref, exists
+}
+
+func (s *Session) CurrentProject() AppProject {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.project
+}
+
+func (s *Session) SetCurrentProject(project AppProject) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.project = project
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionsAreIndependent(t *testing.T) {
+
+	var out1, out2 bytes.Buffer
+	s1, s2 := NewSession(), NewSession()
+	s1.SetOutput(&out1)
+	s2.SetOutput(&out2)
+
+	s1.SetVerbose(true)
+	s1.Logf("building %s\n", "app1")
+	s2.Logf("building %s\n", "app2")
+	assert.Equal(t, "building app1\n", out1.String())
+	assert.Empty(t, out2.String())
+
+	s1.RegisterAlias("AIflow:activity", "log", "github.com/r2d2-ai/contrib/activity/log")
+	ref, found := s1.GetAliasRef("AIflow:activity", "#log")
+	assert.True(t, found)
+	assert.Equal(t, "github.com/r2d2-ai/contrib/activity/log", ref)
+	_, found = s2.GetAliasRef("AIflow:activity", "log")
+	assert.False(t, found)
+
+	s1.Setenv("GOOS", "windows")
+	assert.Equal(t, "windows", s1.GOOS())
+	assert.Equal(t, os.Getenv("GOOS"), s2.Getenv("GOOS"))
+	assert.Contains(t, s1.Environ(), "GOOS=windows")
+
+	// a fork keeps the settings of the session but not its aliases
+	fork := s1.Fork()
+	assert.Equal(t, "windows", fork.GOOS())
+	assert.True(t, fork.Verbose())
+	_, found = fork.GetAliasRef("AIflow:activity", "log")
+	assert.False(t, found)
+	fork.Setenv("GOOS", "linux")
+	assert.Equal(t, "windows", s1.GOOS())
+
+	assert.Nil(t, s1.CurrentProject())
+	assert.Equal(t, DefaultSession(), ProjectSession(nil))
+}
@@ -6,6 +6,7 @@ require (
 	github.com/r2d2-ai/aiflow v0.1.1
 	github.com/spf13/cobra v1.2.1
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 go 1.16
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -19,6 +20,50 @@ type PartialAppDescriptor struct {
 
 type void struct{}
 
+type ImportConflictKind int
+
+const (
+	DuplicateImport ImportConflictKind = iota // the same import is declared more than once
+	VersionConflict                           // a module is imported with different versions
+	AliasConflict                             // an alias is used by more than one import of the same contribution type
+)
+
+// ImportConflict describes a problem between imports of an app
+type ImportConflict struct {
+	Kind        ImportConflictKind
+	Alias       string
+	ContribType string
+	Imports     []Import
+}
+
+func (c *ImportConflict) String() string {
+
+	var imports []string
+	for _, imp := range c.Imports {
+		imports = append(imports, "'"+imp.CanonicalImport()+"'")
+	}
+
+	switch c.Kind {
+	case DuplicateImport:
+		return fmt.Sprintf("import %s is declared more than once, remove the duplicate from the imports", imports[0])
+	case VersionConflict:
+		return fmt.Sprintf("module '%s' is imported with conflicting versions by %s, use the same version for all of them or run 'AIflow imports resolve'",
+			c.Imports[0].ModulePath(), strings.Join(imports, ", "))
+	case AliasConflict:
+		return fmt.Sprintf("alias '%s' is used by %d %s imports: %s, give each of them a distinct alias (ex. \"myalias %s\")",
+			c.Alias, len(imports), c.ContribType, strings.Join(imports, ", "), c.Imports[1].GoImportPath())
+	}
+
+	return strings.Join(imports, ", ")
+}
+
+func sortImports(imports []Import) []Import {
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].CanonicalImport() < imports[j].CanonicalImport()
+	})
+	return imports
+}
+
 type AppImportDetails struct {
 	Imp          Import
 	ContribDesc  *AIflowContribDescriptor
@@ -49,6 +94,7 @@ func (d *AppImportDetails) IsCoreContrib() bool {
 type AppImports struct {
 	imports     map[string]*AppImportDetails
 	orphanedRef map[string]void
+	conflicts   []*ImportConflict
 
 	resolveContribs bool
 	depManager      DepManager
@@ -61,8 +107,12 @@ func (ai *AppImports) addImports(imports []string) error {
 			return err
 		}
 
-		if _, exists := ai.imports[flowImport.GoImportPath()]; exists {
-			//todo warn about duplicate import?
+		if existing, exists := ai.imports[flowImport.GoImportPath()]; exists {
+			kind := DuplicateImport
+			if existing.Imp.Version() != "" && flowImport.Version() != "" && existing.Imp.Version() != flowImport.Version() {
+				kind = VersionConflict
+			}
+			ai.conflicts = append(ai.conflicts, &ImportConflict{Kind: kind, Imports: []Import{existing.Imp, flowImport}})
 			continue
 		}
 
@@ -157,6 +207,52 @@ func (ai *AppImports) GetOrphanedReferences() []string {
 	return refs
 }
 
+// GetImportConflicts returns the duplicate imports, the modules imported with conflicting versions and
+// the aliases used by more than one import of the same contribution type. Alias conflicts can only be
+// detected when the contributions are resolved.
+func (ai *AppImports) GetImportConflicts() []*ImportConflict {
+
+	conflicts := make([]*ImportConflict, len(ai.conflicts))
+	copy(conflicts, ai.conflicts)
+
+	moduleImports := make(map[string][]Import)
+	aliasImports := make(map[string][]Import)
+
+	for _, details := range ai.imports {
+		if details.Imp.Version() != "" {
+			moduleImports[details.Imp.ModulePath()] = append(moduleImports[details.Imp.ModulePath()], details.Imp)
+		}
+
+		if details.TopLevel && details.ContribDesc != nil {
+			key := details.Imp.CanonicalAlias() + " " + details.ContribDesc.GetContribType()
+			aliasImports[key] = append(aliasImports[key], details.Imp)
+		}
+	}
+
+	for _, imports := range moduleImports {
+		versions := make(map[string]void)
+		for _, imp := range imports {
+			versions[imp.Version()] = void{}
+		}
+		if len(versions) > 1 {
+			conflicts = append(conflicts, &ImportConflict{Kind: VersionConflict, Imports: sortImports(imports)})
+		}
+	}
+
+	for key, imports := range aliasImports {
+		if len(imports) > 1 {
+			parts := strings.SplitN(key, " ", 2)
+			conflicts = append(conflicts, &ImportConflict{Kind: AliasConflict, Alias: parts[0], ContribType: parts[1], Imports: sortImports(imports)})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].String() < conflicts[j].String()
+	})
+
+	return conflicts
+}
+
 func (ai *AppImports) GetAllImports() []Import {
 	var allImports []Import
 	for _, details := range ai.imports {
@@ -277,3 +373,110 @@ func extractReferences(ai *AppImports, item interface{}, contribType string) err
 
 	return nil
 }
+
+// RewriteAppRefs walks the refs of an app descriptor the same way the imports are extracted, calling
+// rewrite with each ref and the contribType it is expected to be. If rewrite returns true the ref is
+// replaced. The number of rewritten refs is returned.
+func RewriteAppRefs(appObj map[string]interface{}, rewrite func(ref string, contribType string) (string, bool)) int {
+
+	count := 0
+
+	//triggers
+	if triggers, ok := appObj["triggers"].([]interface{}); ok {
+		for _, trg := range triggers {
+			if trgMap, ok := trg.(map[string]interface{}); ok {
+
+				if strVal, ok := trgMap["ref"].(string); ok {
+					if newRef, changed := rewrite(strVal, "trigger"); changed {
+						trgMap["ref"] = newRef
+						count++
+					}
+				}
+
+				// actions are under handlers, so assume an action contribType
+				count += rewriteReferences(trgMap["handlers"], "action", rewrite)
+			}
+		}
+	}
+
+	//in actions section, refs should be to actions
+	count += rewriteReferences(appObj["actions"], "action", rewrite)
+
+	//in resources section, refs should be to activities
+	count += rewriteReferences(appObj["resources"], "activity", rewrite)
+
+	return count
+}
+
+func rewriteReferences(item interface{}, contribType string, rewrite func(ref string, contribType string) (string, bool)) int {
+	count := 0
+
+	switch t := item.(type) {
+	case map[string]interface{}:
+		for key, val := range t {
+			if strVal, ok := val.(string); ok {
+				if key == "ref" {
+					if newRef, changed := rewrite(strVal, contribType); changed {
+						t[key] = newRef
+						count++
+					}
+				}
+			} else {
+				count += rewriteReferences(val, contribType, rewrite)
+			}
+		}
+	case []interface{}:
+		for _, val := range t {
+			count += rewriteReferences(val, contribType, rewrite)
+		}
+	default:
+	}
+
+	return count
+}
+
+// WalkAppContribConfigs walks the contribution configs of an app descriptor, the objects with a ref, the same
+// way the imports are extracted, calling visit with each config and the contribType it is expected to be
+func WalkAppContribConfigs(appObj map[string]interface{}, visit func(config map[string]interface{}, contribType string)) {
+
+	//triggers
+	if triggers, ok := appObj["triggers"].([]interface{}); ok {
+		for _, trg := range triggers {
+			if trgMap, ok := trg.(map[string]interface{}); ok {
+
+				if _, ok := trgMap["ref"].(string); ok {
+					visit(trgMap, "trigger")
+				}
+
+				// actions are under handlers, so assume an action contribType
+				walkContribConfigs(trgMap["handlers"], "action", visit)
+			}
+		}
+	}
+
+	//in actions section, refs should be to actions
+	walkContribConfigs(appObj["actions"], "action", visit)
+
+	//in resources section, refs should be to activities
+	walkContribConfigs(appObj["resources"], "activity", visit)
+}
+
+func walkContribConfigs(item interface{}, contribType string, visit func(config map[string]interface{}, contribType string)) {
+
+	switch t := item.(type) {
+	case map[string]interface{}:
+		if _, ok := t["ref"].(string); ok {
+			visit(t, contribType)
+		}
+		for _, val := range t {
+			if _, ok := val.(string); !ok {
+				walkContribConfigs(val, contribType, visit)
+			}
+		}
+	case []interface{}:
+		for _, val := range t {
+			walkContribConfigs(val, contribType, visit)
+		}
+	default:
+	}
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var refsAppJson = `{
+  "imports": [
+    "github.com/r2d2-ai/aiflow/trigger/net/rest",
+    "github.com/r2d2-ai/aiflow/action/flow",
+    "github.com/r2d2-ai/aiflow/activity/common/log"
+  ],
+  "triggers": [
+    {
+      "id": "my_rest_trigger",
+      "ref": "#rest",
+      "handlers": [
+        {
+          "action": {
+            "ref": "#flow",
+            "settings": { "flowURI": "res://flow:simple_flow" }
+          }
+        }
+      ]
+    }
+  ],
+  "resources": [
+    {
+      "id": "flow:simple_flow",
+      "data": {
+        "tasks": [
+          { "id": "log", "activity": { "ref": "#log" } },
+          { "id": "rest", "activity": { "ref": "#rest" } }
+        ]
+      }
+    }
+  ]
+}`
+
+func TestRewriteAppRefs(t *testing.T) {
+	var appObj map[string]interface{}
+	err := json.Unmarshal([]byte(refsAppJson), &appObj)
+	assert.Nil(t, err)
+
+	found := make(map[string]string)
+	count := RewriteAppRefs(appObj, func(ref string, contribType string) (string, bool) {
+		found[ref+"|"+contribType] = contribType
+		if ref == "#rest" && contribType == "trigger" {
+			return "#httpTrigger", true
+		}
+		return ref, false
+	})
+
+	assert.Equal(t, 1, count)
+	assert.Contains(t, found, "#rest|trigger")
+	assert.Contains(t, found, "#flow|action")
+	assert.Contains(t, found, "#log|activity")
+	assert.Contains(t, found, "#rest|activity")
+
+	trg := appObj["triggers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "#httpTrigger", trg["ref"])
+
+	tasks := appObj["resources"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})["tasks"].([]interface{})
+	assert.Equal(t, "#rest", tasks[1].(map[string]interface{})["activity"].(map[string]interface{})["ref"])
+}
+
+func TestGetImportConflicts(t *testing.T) {
+	ai := &AppImports{imports: make(map[string]*AppImportDetails), orphanedRef: make(map[string]void)}
+
+	err := ai.addImports([]string{
+		"github.com/r2d2-ai/aiflow/common@v1.0.0:/activity/log",
+		"github.com/r2d2-ai/aiflow/common@v1.1.0:/activity/rest",
+		"github.com/r2d2-ai/aiflow/action/flow",
+		"github.com/r2d2-ai/aiflow/action/flow",
+		"github.com/r2d2-ai/aiflow/trigger/net/rest@v1.0.0",
+		"github.com/r2d2-ai/aiflow/trigger/net/rest@v1.2.0",
+	})
+	assert.Nil(t, err)
+
+	conflicts := ai.GetImportConflicts()
+	assert.Len(t, conflicts, 3)
+
+	kinds := make(map[ImportConflictKind]int)
+	for _, conflict := range conflicts {
+		kinds[conflict.Kind]++
+	}
+	assert.Equal(t, 1, kinds[DuplicateImport])
+	assert.Equal(t, 2, kinds[VersionConflict])
+}
+
+func TestGetImportConflictsAlias(t *testing.T) {
+	ai := &AppImports{imports: make(map[string]*AppImportDetails), orphanedRef: make(map[string]void)}
+
+	err := ai.addImports([]string{
+		"github.com/r2d2-ai/aiflow/trigger/net/rest",
+		"rest github.com/r2d2-ai/aiflow/trigger/net/http",
+		"rest github.com/r2d2-ai/aiflow/activity/rest",
+	})
+	assert.Nil(t, err)
+
+	ai.imports["github.com/r2d2-ai/aiflow/trigger/net/rest"].ContribDesc = &AIflowContribDescriptor{Type: "AIflow:trigger"}
+	ai.imports["github.com/r2d2-ai/aiflow/trigger/net/http"].ContribDesc = &AIflowContribDescriptor{Type: "AIflow:trigger"}
+	ai.imports["github.com/r2d2-ai/aiflow/activity/rest"].ContribDesc = &AIflowContribDescriptor{Type: "AIflow:activity"}
+
+	conflicts := ai.GetImportConflicts()
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, AliasConflict, conflicts[0].Kind)
+	assert.Equal(t, "rest", conflicts[0].Alias)
+	assert.Len(t, conflicts[0].Imports, 2)
+}
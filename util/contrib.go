@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -20,14 +21,125 @@ type AIflowContribDescriptor struct {
 	Name        string `json:"name"`
 	Type        string `json:"type"`
 	Version     string `json:"version"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
 	Description string `json:"description"`
 	Homepage    string `json:"homepage"`
 	Shim        string `json:"shim"`
 	Ref         string `json:"ref"` //legacy
 
+	Settings []*ContribAttribute `json:"settings"`
+	Input    []*ContribAttribute `json:"input"`
+	Output   []*ContribAttribute `json:"output"`
+	Reply    []*ContribAttribute `json:"reply"`
+	Inputs   []*ContribAttribute `json:"inputs"`  //legacy
+	Outputs  []*ContribAttribute `json:"outputs"` //legacy
+	Handler  *ContribHandler     `json:"handler"`
+	Examples []interface{}       `json:"examples"`
+
 	IsLegacy bool `json:"-"`
 }
 
+// ContribAttribute is a setting, input or output declared in a contribution descriptor
+type ContribAttribute struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Required    bool          `json:"required"`
+	Value       interface{}   `json:"value"`
+	Allowed     []interface{} `json:"allowed"`
+	Description string        `json:"description"`
+}
+
+// ContribHandler describes the handler settings of a trigger
+type ContribHandler struct {
+	Settings []*ContribAttribute `json:"settings"`
+}
+
+// UnmarshalJSON decodes the descriptor leniently, the attributes, handler and examples that don't have the expected
+// shape are ignored rather than failing the whole descriptor
+func (d *AIflowContribDescriptor) UnmarshalJSON(data []byte) error {
+
+	type descriptor AIflowContribDescriptor
+	aux := &struct {
+		*descriptor
+		Settings json.RawMessage `json:"settings"`
+		Input    json.RawMessage `json:"input"`
+		Output   json.RawMessage `json:"output"`
+		Reply    json.RawMessage `json:"reply"`
+		Inputs   json.RawMessage `json:"inputs"`
+		Outputs  json.RawMessage `json:"outputs"`
+		Handler  json.RawMessage `json:"handler"`
+		Examples json.RawMessage `json:"examples"`
+	}{descriptor: (*descriptor)(d)}
+
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	d.Settings = decodeContribAttributes(aux.Settings)
+	d.Input = decodeContribAttributes(aux.Input)
+	d.Output = decodeContribAttributes(aux.Output)
+	d.Reply = decodeContribAttributes(aux.Reply)
+	d.Inputs = decodeContribAttributes(aux.Inputs)
+	d.Outputs = decodeContribAttributes(aux.Outputs)
+
+	d.Handler = nil
+	var handler map[string]json.RawMessage
+	if json.Unmarshal(aux.Handler, &handler) == nil && handler != nil {
+		d.Handler = &ContribHandler{Settings: decodeContribAttributes(handler["settings"])}
+	}
+
+	d.Examples = nil
+	_ = json.Unmarshal(aux.Examples, &d.Examples)
+
+	return nil
+}
+
+// UnmarshalJSON decodes each field of the attribute separately, a field of the wrong type is left empty and a
+// 'required' given as a string is parsed
+func (a *ContribAttribute) UnmarshalJSON(data []byte) error {
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*a = ContribAttribute{}
+	_ = json.Unmarshal(fields["name"], &a.Name)
+	_ = json.Unmarshal(fields["type"], &a.Type)
+	_ = json.Unmarshal(fields["value"], &a.Value)
+	_ = json.Unmarshal(fields["allowed"], &a.Allowed)
+	_ = json.Unmarshal(fields["description"], &a.Description)
+
+	if json.Unmarshal(fields["required"], &a.Required) != nil {
+		var required string
+		if json.Unmarshal(fields["required"], &required) == nil {
+			a.Required, _ = strconv.ParseBool(required)
+		}
+	}
+
+	return nil
+}
+
+// decodeContribAttributes decodes a list of attributes, the entries that aren't objects are skipped
+func decodeContribAttributes(data json.RawMessage) []*ContribAttribute {
+
+	var entries []json.RawMessage
+	if json.Unmarshal(data, &entries) != nil {
+		return nil
+	}
+
+	var attrs []*ContribAttribute
+	for _, entry := range entries {
+		attr := &ContribAttribute{}
+		if json.Unmarshal(entry, attr) == nil {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
+
 type AIflowContribBundleDescriptor struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
@@ -42,6 +154,22 @@ func (d *AIflowContribDescriptor) GetContribType() string {
 	return ""
 }
 
+// GetInput returns the input attributes, including the legacy 'inputs'
+func (d *AIflowContribDescriptor) GetInput() []*ContribAttribute {
+	if len(d.Input) == 0 {
+		return d.Inputs
+	}
+	return d.Input
+}
+
+// GetOutput returns the output attributes, including the legacy 'outputs'
+func (d *AIflowContribDescriptor) GetOutput() []*ContribAttribute {
+	if len(d.Output) == 0 {
+		return d.Outputs
+	}
+	return d.Output
+}
+
 func GetContribDescriptorFromImport(depManager DepManager, contribImport Import) (*AIflowContribDescriptor, error) {
 
 	contribPath, err := depManager.GetPath(contribImport)
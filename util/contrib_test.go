@@ -0,0 +1,53 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var offShapeDescriptorJson = `{
+  "name": "sample",
+  "type": "AIflow:activity",
+  "version": "0.0.1",
+  "settings": [
+    { "name": "url", "type": "string", "required": "true", "allowed": "GET" },
+    { "name": "timeout", "type": "integer", "required": 1 },
+    "method"
+  ],
+  "input": { "name": "message" },
+  "output": [
+    { "name": "result", "type": "any", "value": { "a": 1 } }
+  ],
+  "handler": [ "settings" ],
+  "examples": "see the readme"
+}`
+
+func TestReadContribDescriptorOffShape(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "descriptor")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tempDir)
+
+	descriptorFile := filepath.Join(tempDir, "descriptor.json")
+	assert.Nil(t, ioutil.WriteFile(descriptorFile, []byte(offShapeDescriptorJson), 0644))
+
+	desc, err := ReadContribDescriptor(descriptorFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "activity", desc.GetContribType())
+
+	assert.Len(t, desc.Settings, 2)
+	assert.Equal(t, "url", desc.Settings[0].Name)
+	assert.True(t, desc.Settings[0].Required)
+	assert.Nil(t, desc.Settings[0].Allowed)
+	assert.Equal(t, "timeout", desc.Settings[1].Name)
+	assert.False(t, desc.Settings[1].Required)
+
+	assert.Nil(t, desc.Input)
+	assert.Len(t, desc.GetOutput(), 1)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, desc.Output[0].Value)
+	assert.Nil(t, desc.Handler)
+	assert.Nil(t, desc.Examples)
+}
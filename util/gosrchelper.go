@@ -130,7 +130,8 @@ func GetGoPath() string {
 	if !set {
 		out, err := exec.Command("go", "env", "GOPATH").Output()
 		if err != nil {
-			log.Fatal(err)
+			// go isn't available, reported by the callers as an unset GOPATH
+			return ""
 		}
 		goPathCached = strings.TrimSuffix(string(out), "\n")
 	}
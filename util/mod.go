@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -23,18 +24,56 @@ type DepManager interface {
 	GetAllImports() (map[string]Import, error)
 }
 
+// ExecEnv is the environment the commands of a DepManager are run in
+type ExecEnv interface {
+	// ExecCmd runs the command in the working directory, see ExecCmd
+	ExecCmd(cmd *exec.Cmd, workingDir string) error
+	Getenv(key string) string
+}
+
 func NewDepManager(sourceDir string) DepManager {
 	return &ModDepManager{srcDir: sourceDir, localMods: make(map[string]string)}
 }
 
+// NewDepManagerInEnv returns a DepManager running the go commands in the environment
+func NewDepManagerInEnv(sourceDir string, env ExecEnv) DepManager {
+	return &ModDepManager{srcDir: sourceDir, localMods: make(map[string]string), env: env}
+}
+
 type ModDepManager struct {
-	srcDir    string
-	localMods map[string]string
+	srcDir     string
+	localMods  map[string]string
+	env        ExecEnv // environment of the commands, the process if nil
+	downloaded bool    // whether the modules of go.mod were already downloaded for the build
+}
+
+func (m *ModDepManager) execCmd(cmd *exec.Cmd) error {
+	if m.env == nil {
+		return ExecCmd(cmd, m.srcDir)
+	}
+	return m.env.ExecCmd(cmd, m.srcDir)
+}
+
+// goPath returns the GOPATH of the environment, or of the go command if it isn't set
+func (m *ModDepManager) goPath() string {
+	if m.env == nil {
+		return GetGoPath()
+	}
+	if goPath := m.env.Getenv("GOPATH"); goPath != "" {
+		return goPath
+	}
+	return GetGoPath()
+}
+
+// SetModulesDownloaded records that the modules of go.mod were downloaded, AddReplacedContribForBuild doesn't
+// download them again
+func (m *ModDepManager) SetModulesDownloaded() {
+	m.downloaded = true
 }
 
 func (m *ModDepManager) Init() error {
 
-	err := ExecCmd(exec.Command("go", "mod", "init", "main"), m.srcDir)
+	err := m.execCmd(exec.Command("go", "mod", "init", "main"))
 	if err == nil {
 		return err
 	}
@@ -47,14 +86,14 @@ func (m *ModDepManager) AddDependency(flowImport Import) error {
 	// todo: optimize the following
 
 	// use "go mod edit" (instead of "go get") as first method
-	err := ExecCmd(exec.Command("go", "mod", "edit", "-require", flowImport.GoModImportPath()), m.srcDir)
+	err := m.execCmd(exec.Command("go", "mod", "edit", "-require", flowImport.GoModImportPath()))
 	if err != nil {
 		return err
 	}
 
-	err = ExecCmd(exec.Command("go", "mod", "verify"), m.srcDir)
+	err = m.execCmd(exec.Command("go", "mod", "verify"))
 	if err == nil {
-		err = ExecCmd(exec.Command("go", "mod", "download", flowImport.ModulePath()), m.srcDir)
+		err = m.execCmd(exec.Command("go", "mod", "download", flowImport.ModulePath()))
 	}
 
 	if err != nil {
@@ -65,7 +104,7 @@ func (m *ModDepManager) AddDependency(flowImport Import) error {
 		if flowImport.IsClassic() {
 			m.RemoveImport(flowImport)
 
-			err = ExecCmd(exec.Command("go", "get", flowImport.GoGetImportPath()), m.srcDir)
+			err = m.execCmd(exec.Command("go", "get", flowImport.GoGetImportPath()))
 		}
 	}
 
@@ -79,11 +118,6 @@ func (m *ModDepManager) AddDependency(flowImport Import) error {
 // GetPath gets the path of where the
 func (m *ModDepManager) GetPath(flowImport Import) (string, error) {
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-
 	pkg := flowImport.ModulePath()
 
 	path, ok := m.localMods[pkg]
@@ -91,11 +125,12 @@ func (m *ModDepManager) GetPath(flowImport Import) (string, error) {
 
 		return path, nil
 	}
-	defer os.Chdir(currentDir)
-
-	os.Chdir(m.srcDir)
 
+	// go.mod is read by its path, the working directory of the process is shared by concurrent builds
 	file, err := os.Open(filepath.Join(m.srcDir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
 	defer file.Close()
 
 	var pathForPartial string
@@ -136,9 +171,9 @@ func (m *ModDepManager) GetPath(flowImport Import) (string, error) {
 				tempPath = strings.Split(remaining, "/")
 				remainingPath := filepath.Join(tempPath...)
 
-				pathForPartial = filepath.Join(os.Getenv("GOPATH"), "pkg", "mod", pkgPath, remainingPath)
+				pathForPartial = filepath.Join(m.goPath(), "pkg", "mod", pkgPath, remainingPath)
 			} else {
-				return filepath.Join(os.Getenv("GOPATH"), "pkg", "mod", pkgPath, flowImport.RelativeImportPath()), nil
+				return filepath.Join(m.goPath(), "pkg", "mod", pkgPath, flowImport.RelativeImportPath()), nil
 			}
 		}
 	}
@@ -147,17 +182,8 @@ func (m *ModDepManager) GetPath(flowImport Import) (string, error) {
 
 func (m *ModDepManager) RemoveImport(flowImport Import) error {
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
 	modulePath := flowImport.ModulePath()
 
-	defer os.Chdir(currentDir)
-
-	os.Chdir(m.srcDir)
-
 	file, err := os.Open(filepath.Join(m.srcDir, "go.mod"))
 	if err != nil {
 		return err
@@ -200,10 +226,16 @@ func (m *ModDepManager) GetAllImports() (map[string]Import, error) {
 	}
 
 	content := string(file)
-
-	imports := strings.Split(content[strings.Index(content, "(")+1:strings.Index(content, ")")], "\n")
 	result := make(map[string]Import)
 
+	// go.mod without a require block
+	start, end := strings.Index(content, "("), strings.Index(content, ")")
+	if start < 0 || end < start {
+		return result, nil
+	}
+
+	imports := strings.Split(content[start+1:end], "\n")
+
 	for _, pkg := range imports {
 		if pkg != " " && pkg != "" {
 
@@ -252,6 +284,12 @@ func Verbose() bool {
 }
 
 func ExecCmd(cmd *exec.Cmd, workingDir string) error {
+	return ExecCmdOutput(cmd, workingDir, verbose, os.Stdout, os.Stderr)
+}
+
+// ExecCmdOutput runs the command in the working directory, its output is written to stdout and stderr when
+// printOutput is set, the error output of a failed command is returned as the error
+func ExecCmdOutput(cmd *exec.Cmd, workingDir string, printOutput bool, stdout, stderr io.Writer) error {
 
 	if workingDir != "" {
 		cmd.Dir = workingDir
@@ -259,9 +297,9 @@ func ExecCmd(cmd *exec.Cmd, workingDir string) error {
 
 	var out bytes.Buffer
 
-	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	if printOutput {
+		cmd.Stdout = stdout
+		cmd.Stderr = io.MultiWriter(stderr, &out)
 	} else {
 		cmd.Stdout = nil
 		cmd.Stderr = &out
@@ -278,9 +316,11 @@ func ExecCmd(cmd *exec.Cmd, workingDir string) error {
 
 func (m *ModDepManager) AddReplacedContribForBuild() error {
 
-	err := ExecCmd(exec.Command("go", "mod", "download"), m.srcDir)
-	if err != nil {
-		return err
+	if !m.downloaded {
+		err := m.execCmd(exec.Command("go", "mod", "download"))
+		if err != nil {
+			return err
+		}
 	}
 
 	text, err := ioutil.ReadFile(filepath.Join(m.srcDir, "go.mod"))
@@ -302,10 +342,15 @@ func (m *ModDepManager) AddReplacedContribForBuild() error {
 				// the local pkg.
 				if len(mods) < 5 {
 
-					m.localMods[mods[1]] = mods[3]
+					// a relative replacement is relative to src, not to the working directory
+					localPath := mods[3]
+					if !filepath.IsAbs(localPath) {
+						localPath = filepath.Join(m.srcDir, localPath)
+					}
+					m.localMods[mods[1]] = localPath
 				} else {
 
-					m.localMods[mods[1]] = filepath.Join(os.Getenv("GOPATH"), "pkg", "mod", mods[3]+"@"+mods[4])
+					m.localMods[mods[1]] = filepath.Join(m.goPath(), "pkg", "mod", mods[3]+"@"+mods[4])
 				}
 
 			}
@@ -330,7 +375,7 @@ func (m *ModDepManager) InstallReplacedPkg(pkg1 string, pkg2 string) error {
 		return err
 	}
 
-	err = ExecCmd(exec.Command("go", "mod", "download"), m.srcDir)
+	err = m.execCmd(exec.Command("go", "mod", "download"))
 	if err != nil {
 		return err
 	}
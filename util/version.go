@@ -12,16 +12,16 @@ import (
 )
 
 const (
-	cliPackage = "github.com/r2d2-ai/aiflow-cli"
+	CLIPackage = "github.com/r2d2-ai/aiflow-cli"
 )
 
 func GetCLIInfo() (string, string, error) {
 
-	path, ver, err := FindOldPackageSrc(cliPackage)
+	path, ver, err := FindOldPackageSrc(CLIPackage)
 
 	if IsPkgNotFoundError(err) {
 		//must be using the new go mod layout
-		path, ver, err = FindGoModPackageSrc(cliPackage, "", true)
+		path, ver, err = FindGoModPackageSrc(CLIPackage, "", true)
 		if err != nil {
 			workingDir, _ := os.Getwd()
 			ver = GetPackageVersionFromGit(workingDir)
@@ -31,6 +31,17 @@ func GetCLIInfo() (string, string, error) {
 	return path, ver, err
 }
 
+// FindCLISource returns the location of the CLI source, needed to rebuild the CLI with plugins
+func FindCLISource() (string, error) {
+
+	path, _, err := FindOldPackageSrc(CLIPackage)
+	if IsPkgNotFoundError(err) {
+		path, _, err = FindGoModPackageSrc(CLIPackage, "", true)
+	}
+
+	return path, err
+}
+
 // retrieve the package version from source in GOPATH using "git describe" command
 func GetPackageVersionFromSource(pkg string) string {
 	gopath := GetGoPath()